@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
@@ -12,11 +13,20 @@ import (
 	"github.com/gookit/slog/handler"
 
 	"github.com/keshon/dice-roller/internal/botsdef"
+	"github.com/keshon/dice-roller/internal/channels"
 	"github.com/keshon/dice-roller/internal/config"
 	"github.com/keshon/dice-roller/internal/db"
 	"github.com/keshon/dice-roller/internal/manager"
+	"github.com/keshon/dice-roller/internal/ratelimit"
 	"github.com/keshon/dice-roller/internal/rest"
+	"github.com/keshon/dice-roller/internal/router"
+	"github.com/keshon/dice-roller/internal/settings"
 	"github.com/keshon/dice-roller/internal/version"
+
+	// modules register themselves with botsdef
+	_ "github.com/keshon/dice-roller/mod-about/discord"
+	_ "github.com/keshon/dice-roller/mod-dicer/discord"
+	_ "github.com/keshon/dice-roller/mod-generator/discord"
 )
 
 // main is the entry point of the program.
@@ -28,11 +38,15 @@ func main() {
 	config := loadConfig()
 	initDatabase()
 	discordSession := createDiscordSession(config.DiscordBotToken)
-	bots := startBotHandlers(discordSession)
+	ctx, stopBots := context.WithCancel(context.Background())
+	guildSettings := settings.NewStore(settings.Defaults(config.DiscordCommandPrefix))
+	limiter := ratelimit.New()
+	guilds := startBotHandlers(ctx, discordSession, config, guildSettings, limiter)
 	handleDiscordSession(discordSession)
-	startRestServer(config, bots)
+	startRestServer(config, guilds, guildSettings, limiter)
 	slog.Infof("%v is now running. Press Ctrl+C to exit", version.AppName)
 	waitForExitSignal()
+	stopBots()
 }
 
 // initLogger initializes the logger with color theme and file handler.
@@ -95,31 +109,31 @@ func createDiscordSession(token string) *discordgo.Session {
 
 // startBotHandlers initializes and starts Discord bot handlers for each guild.
 //
-// It takes a *discordgo.Session as a parameter and returns a map[string]map[string]botsdef.Discord.
-func startBotHandlers(session *discordgo.Session) map[string]map[string]botsdef.Discord {
-	bots := make(map[string]map[string]botsdef.Discord)
-
-	guildIDs, err := db.GetAllGuildIDs()
-	if err != nil {
-		log.Fatal("Error retrieving or creating guilds", err)
-	}
+// It takes a context stopping the handlers when done, a *discordgo.Session, a config.Config pointer, the settings
+// of the guilds and the rate limiter of the commands as parameters and returns the registry of the registered guilds
+// and their modules.
+func startBotHandlers(ctx context.Context, session *discordgo.Session, config *config.Config, guildSettings *settings.Store, limiter *ratelimit.Limiter) *botsdef.GuildRegistry {
+	guilds := botsdef.NewGuildRegistry()
+	guilds.Subscribe(func(e botsdef.GuildEvent) {
+		if e.Type == botsdef.ModuleAdded {
+			slog.Infof("Module %v added to guild %v", e.Module, e.GuildID)
+			return
+		}
+		slog.Infof("Guild %v %v", e.GuildID, e.Type)
+	})
 
-	for _, id := range guildIDs {
-		bots[id] = make(map[string]botsdef.Discord)
+	commandRouter := router.New(session, config.DiscordCommandPrefix)
+	commandRouter.Settings = guildSettings
+	commandRouter.Limiter = limiter
+	commandRouter.Channels = channels.NewStore()
+	commandRouter.Start()
 
-		for _, module := range botsdef.Modules {
-			botInstance := botsdef.CreateBotInstance(session, module)
-			if botInstance != nil {
-				bots[id][module] = botInstance
-				botInstance.Start(id)
-			}
-		}
+	guildManager := manager.NewGuildManager(session, commandRouter, guilds)
+	if err := guildManager.Start(ctx); err != nil {
+		log.Fatal("Error starting guild manager", err)
 	}
 
-	guildManager := manager.NewGuildManager(session, bots)
-	guildManager.Start()
-
-	return bots
+	return guilds
 }
 
 // handleDiscordSession is a Go function that opens a Discord session and handles any errors.
@@ -134,10 +148,11 @@ func handleDiscordSession(discordSession *discordgo.Session) {
 	defer discordSession.Close()
 }
 
-// startRestServer starts the REST server based on the given configuration and bot instances.
+// startRestServer starts the REST server based on the given configuration and guild registry.
 //
-// It takes a config.Config pointer and a map of string to *discord.BotInstance as parameters.
-func startRestServer(config *config.Config, bots map[string]map[string]botsdef.Discord) {
+// It takes a config.Config pointer, the registry of the registered guilds, their settings and the rate limiter
+// of the commands as parameters.
+func startRestServer(config *config.Config, guilds *botsdef.GuildRegistry, guildSettings *settings.Store, limiter *ratelimit.Limiter) {
 	if !config.RestEnabled {
 		return
 	}
@@ -145,7 +160,7 @@ func startRestServer(config *config.Config, bots map[string]map[string]botsdef.D
 		gin.SetMode("release")
 	}
 	router := gin.Default()
-	restAPI := rest.NewRest(bots)
+	restAPI := rest.NewRest(guilds, guildSettings, limiter)
 	restAPI.Start(router)
 	go func() {
 		if len(config.RestHostname) == 0 {
@@ -9,25 +9,25 @@ import (
 func TestStartRestServer(t *testing.T) {
 	t.Run("RestDisabled", func(t *testing.T) {
 		config := &config.Config{RestEnabled: false}
-		startRestServer(config, nil)
+		startRestServer(config, nil, nil, nil)
 		// Add assertion for expected behavior
 	})
 
 	t.Run("RestGinReleaseEnabled", func(t *testing.T) {
 		config := &config.Config{RestEnabled: true, RestGinRelease: true}
-		startRestServer(config, nil)
+		startRestServer(config, nil, nil, nil)
 		// Add assertion for expected behavior
 	})
 
 	t.Run("EmptyRestHostname", func(t *testing.T) {
 		config := &config.Config{RestEnabled: true, RestGinRelease: false, RestHostname: ""}
-		startRestServer(config, nil)
+		startRestServer(config, nil, nil, nil)
 		// Add assertion for expected behavior
 	})
 
 	t.Run("NonEmptyRestHostname", func(t *testing.T) {
 		config := &config.Config{RestEnabled: true, RestGinRelease: false, RestHostname: "localhost:8080"}
-		startRestServer(config, nil)
+		startRestServer(config, nil, nil, nil)
 		// Add assertion for expected behavior
 	})
 }
@@ -1,6 +1,18 @@
 package botsdef
 
+import (
+	"context"
+
+	"github.com/keshon/dice-roller/internal/lifecycle"
+)
+
+// Discord is a module instance serving the guilds it is started in, and direct messages.
 type Discord interface {
-	Start(guildID string)
-	Stop()
+	// Start serves the guild, or direct messages for router.Direct, until Stop is called or the context is done.
+	// One instance serves every guild, a stopped guild can be started again.
+	Start(ctx context.Context, guildID string) error
+	// Stop stops serving the guild.
+	Stop(guildID string)
+	// State returns the state of the module in the guild.
+	State(guildID string) lifecycle.State
 }
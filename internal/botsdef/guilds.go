@@ -0,0 +1,169 @@
+package botsdef
+
+import (
+	"sort"
+	"sync"
+)
+
+// GuildEventType is the kind of change made to a GuildRegistry.
+type GuildEventType string
+
+const (
+	GuildRegistered   GuildEventType = "registered"
+	GuildUnregistered GuildEventType = "unregistered"
+	ModuleAdded       GuildEventType = "module added"
+)
+
+// GuildEvent is a change made to a GuildRegistry. Module is set for ModuleAdded only.
+type GuildEvent struct {
+	Type    GuildEventType
+	GuildID string
+	Module  string
+}
+
+type subscriber struct {
+	id int
+	fn func(GuildEvent)
+}
+
+// GuildRegistry holds the registered guilds and the modules serving each of them.
+// It is safe for concurrent use, e.g. by Discord event handlers and the REST API.
+type GuildRegistry struct {
+	mu          sync.RWMutex
+	guilds      map[string]map[string]Discord
+	nextID      int
+	subscribers []subscriber
+}
+
+// NewGuildRegistry creates an empty guild registry.
+func NewGuildRegistry() *GuildRegistry {
+	return &GuildRegistry{guilds: make(map[string]map[string]Discord)}
+}
+
+// Subscribe calls fn after each change to the registry, in the goroutine that made it.
+// The returned function unsubscribes.
+func (r *GuildRegistry) Subscribe(fn func(GuildEvent)) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	id := r.nextID
+	r.subscribers = append(r.subscribers, subscriber{id: id, fn: fn})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { r.unsubscribe(id) })
+	}
+}
+
+// unsubscribe removes the subscriber with the given ID.
+func (r *GuildRegistry) unsubscribe(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, s := range r.subscribers {
+		if s.id == id {
+			r.subscribers = append(r.subscribers[:i:i], r.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Register adds the guild. It reports false when the guild is already registered.
+func (r *GuildRegistry) Register(guildID string) bool {
+	r.mu.Lock()
+	if _, ok := r.guilds[guildID]; ok {
+		r.mu.Unlock()
+		return false
+	}
+	r.guilds[guildID] = make(map[string]Discord)
+	subscribers := r.subscribers
+	r.mu.Unlock()
+
+	notify(subscribers, GuildEvent{Type: GuildRegistered, GuildID: guildID})
+	return true
+}
+
+// Unregister removes the guild and returns the modules that served it, nil when it was not registered.
+func (r *GuildRegistry) Unregister(guildID string) map[string]Discord {
+	r.mu.Lock()
+	modules, ok := r.guilds[guildID]
+	if !ok {
+		r.mu.Unlock()
+		return nil
+	}
+	delete(r.guilds, guildID)
+	subscribers := r.subscribers
+	r.mu.Unlock()
+
+	notify(subscribers, GuildEvent{Type: GuildUnregistered, GuildID: guildID})
+	return modules
+}
+
+// AddModule records the module serving the guild. It reports false when the guild is not registered.
+func (r *GuildRegistry) AddModule(guildID, module string, bot Discord) bool {
+	r.mu.Lock()
+	modules, ok := r.guilds[guildID]
+	if !ok {
+		r.mu.Unlock()
+		return false
+	}
+	if existing, ok := modules[module]; ok && existing == bot {
+		r.mu.Unlock()
+		return true
+	}
+	modules[module] = bot
+	subscribers := r.subscribers
+	r.mu.Unlock()
+
+	notify(subscribers, GuildEvent{Type: ModuleAdded, GuildID: guildID, Module: module})
+	return true
+}
+
+// Module returns the module serving the guild.
+func (r *GuildRegistry) Module(guildID, module string) (Discord, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	bot, ok := r.guilds[guildID][module]
+	return bot, ok
+}
+
+// Modules returns a copy of the modules serving the guild.
+func (r *GuildRegistry) Modules(guildID string) map[string]Discord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	modules := make(map[string]Discord, len(r.guilds[guildID]))
+	for name, bot := range r.guilds[guildID] {
+		modules[name] = bot
+	}
+	return modules
+}
+
+// Registered reports whether the guild is registered.
+func (r *GuildRegistry) Registered(guildID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.guilds[guildID]
+	return ok
+}
+
+// Guilds returns the IDs of the registered guilds, sorted.
+func (r *GuildRegistry) Guilds() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.guilds))
+	for id := range r.guilds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// notify calls the subscribers with the event.
+func notify(subscribers []subscriber, event GuildEvent) {
+	for _, s := range subscribers {
+		s.fn(event)
+	}
+}
@@ -0,0 +1,101 @@
+package botsdef
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keshon/dice-roller/internal/lifecycle"
+)
+
+type fakeModule struct{}
+
+func (fakeModule) Start(ctx context.Context, guildID string) error { return nil }
+func (fakeModule) Stop(guildID string)                             {}
+func (fakeModule) State(guildID string) lifecycle.State            { return lifecycle.Running }
+
+func TestGuildRegistry(t *testing.T) {
+	t.Run("RegisterUnregister", func(t *testing.T) {
+		r := NewGuildRegistry()
+		assert.True(t, r.Register("b"))
+		assert.True(t, r.Register("a"))
+		assert.False(t, r.Register("a"))
+		assert.Equal(t, []string{"a", "b"}, r.Guilds())
+
+		assert.True(t, r.AddModule("a", "dicer", fakeModule{}))
+		assert.False(t, r.AddModule("missing", "dicer", fakeModule{}))
+		_, ok := r.Module("a", "dicer")
+		assert.True(t, ok)
+
+		modules := r.Modules("a")
+		delete(modules, "dicer")
+		assert.Len(t, r.Modules("a"), 1, "Modules returns a copy")
+
+		assert.Len(t, r.Unregister("a"), 1)
+		assert.Nil(t, r.Unregister("a"))
+		assert.False(t, r.Registered("a"))
+		assert.Equal(t, []string{"b"}, r.Guilds())
+	})
+
+	t.Run("Notifications", func(t *testing.T) {
+		r := NewGuildRegistry()
+		var events []GuildEvent
+		unsubscribe := r.Subscribe(func(e GuildEvent) { events = append(events, e) })
+
+		r.Register("a")
+		r.Register("a")
+		r.AddModule("a", "dicer", fakeModule{})
+		r.AddModule("a", "dicer", fakeModule{})
+		r.Unregister("a")
+		unsubscribe()
+		unsubscribe()
+		r.Register("b")
+
+		assert.Equal(t, []GuildEvent{
+			{Type: GuildRegistered, GuildID: "a"},
+			{Type: ModuleAdded, GuildID: "a", Module: "dicer"},
+			{Type: GuildUnregistered, GuildID: "a"},
+		}, events)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		r := NewGuildRegistry()
+		var mu sync.Mutex
+		counts := make(map[GuildEventType]int)
+		r.Subscribe(func(e GuildEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			counts[e.Type]++
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			guildID := fmt.Sprint(i)
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				r.Register(guildID)
+				r.AddModule(guildID, "dicer", fakeModule{})
+			}()
+			go func() {
+				defer wg.Done()
+				for _, id := range r.Guilds() {
+					r.Modules(id)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				r.Unregister(guildID)
+			}()
+		}
+		wg.Wait()
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, 100, counts[GuildRegistered])
+		assert.Equal(t, counts[GuildRegistered]-len(r.Guilds()), counts[GuildUnregistered])
+	})
+}
@@ -0,0 +1,138 @@
+package botsdef
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/internal/router"
+)
+
+// Factory creates a module instance.
+type Factory func(session *discordgo.Session, r *router.Router) Discord
+
+// Plugin describes a module and how to create its instances.
+type Plugin struct {
+	Name    string
+	Version string
+	// DefaultEnabled is whether the module runs in guilds that haven't enabled or disabled it.
+	DefaultEnabled bool
+	// Dependencies are the modules that must be enabled for this one to be.
+	Dependencies []string
+	// Direct is whether the module also serves direct messages.
+	Direct bool
+	// New creates the instance of the module, which serves every guild it is started in.
+	New Factory
+}
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = make(map[string]Plugin)
+)
+
+// Register makes a module available, usually from the init function of its package.
+// It panics when the plugin has no name or factory, or when a module with the same name is registered.
+func Register(p Plugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	if p.Name == "" || p.New == nil {
+		panic("botsdef: plugin needs a name and a factory")
+	}
+	if _, ok := plugins[p.Name]; ok {
+		panic("botsdef: plugin registered twice: " + p.Name)
+	}
+	plugins[p.Name] = p
+}
+
+// Lookup returns the registered module with the given name.
+func Lookup(name string) (Plugin, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	p, ok := plugins[name]
+	return p, ok
+}
+
+// Plugins returns the registered modules ordered so that each comes after its dependencies.
+// It fails when a dependency is not registered or dependencies form a cycle.
+func Plugins() ([]Plugin, error) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	return sortPlugins(plugins)
+}
+
+// EnabledModules returns whether each module is enabled in the given guild: its default,
+// unless the guild enabled or disabled it.
+//
+// Parameters:
+// - plugins: the modules
+// - guildID: string
+// Returns the enabled state by module name and an error if the settings can't be loaded.
+func EnabledModules(plugins []Plugin, guildID string) (map[string]bool, error) {
+	enabled := make(map[string]bool, len(plugins))
+	for _, p := range plugins {
+		enabled[p.Name] = p.DefaultEnabled
+	}
+
+	settings, err := db.GetGuildModules(guildID)
+	if err != nil {
+		return nil, err
+	}
+	for _, setting := range settings {
+		if _, ok := enabled[setting.Module]; ok {
+			enabled[setting.Module] = setting.Enabled
+		}
+	}
+
+	return enabled, nil
+}
+
+// sortPlugins orders the plugins by name, each after its dependencies.
+func sortPlugins(plugins map[string]Plugin) ([]Plugin, error) {
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := make([]Plugin, 0, len(plugins))
+	visited := make(map[string]bool)
+	visiting := make(map[string]bool)
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		if visited[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("module dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+
+		p, ok := plugins[name]
+		if !ok {
+			return fmt.Errorf("module %s depends on unknown module %s", path[len(path)-1], name)
+		}
+
+		visiting[name] = true
+		for _, dep := range p.Dependencies {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		sorted = append(sorted, p)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,43 @@
+package botsdef
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortPlugins(t *testing.T) {
+	names := func(plugins []Plugin) []string {
+		var names []string
+		for _, p := range plugins {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	t.Run("DependenciesFirst", func(t *testing.T) {
+		sorted, err := sortPlugins(map[string]Plugin{
+			"about":     {Name: "about"},
+			"dicer":     {Name: "dicer", Dependencies: []string{"tables"}},
+			"tables":    {Name: "tables"},
+			"generator": {Name: "generator", Dependencies: []string{"dicer", "about"}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"about", "tables", "dicer", "generator"}, names(sorted))
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		_, err := sortPlugins(map[string]Plugin{
+			"a": {Name: "a", Dependencies: []string{"b"}},
+			"b": {Name: "b", Dependencies: []string{"a"}},
+		})
+		assert.EqualError(t, err, "module dependency cycle: a -> b -> a")
+	})
+
+	t.Run("UnknownDependency", func(t *testing.T) {
+		_, err := sortPlugins(map[string]Plugin{
+			"dicer": {Name: "dicer", Dependencies: []string{"missing"}},
+		})
+		assert.EqualError(t, err, "module dicer depends on unknown module missing")
+	})
+}
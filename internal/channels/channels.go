@@ -0,0 +1,153 @@
+package channels
+
+import (
+	"sync"
+
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/db"
+)
+
+// Rule is whether the bot, or one of its modules, answers in a channel.
+type Rule struct {
+	ChannelID string
+	// Module is the module the rule overrides the channel rule for, empty for the whole bot.
+	Module string
+	Allow  bool
+}
+
+// Rules are the channel rules of a guild.
+type Rules []Rule
+
+// Allows reports whether the module answers in the channel, module "" for the whole bot.
+// A rule of the module in the channel comes first, then the rule of the channel. Channels without a rule
+// are allowed, unless the guild allows some channels: then only those get answers.
+func (r Rules) Allows(channelID, module string) bool {
+	if module != "" {
+		if rule, ok := r.find(channelID, module); ok {
+			return rule.Allow
+		}
+	}
+	if rule, ok := r.find(channelID, ""); ok {
+		return rule.Allow
+	}
+	return !r.AllowList()
+}
+
+// AllowList reports whether the guild allows some channels, so the bot answers only in them.
+func (r Rules) AllowList() bool {
+	for _, rule := range r {
+		if rule.Module == "" && rule.Allow {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the rule of the module in the channel.
+func (r Rules) find(channelID, module string) (Rule, bool) {
+	for _, rule := range r {
+		if rule.ChannelID == channelID && rule.Module == module {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Store loads the channel rules of the guilds from the database and caches them. It is safe for concurrent use.
+type Store struct {
+	mu    sync.RWMutex
+	cache map[string]Rules
+
+	load   func(guildID string) ([]db.ChannelRule, error)
+	save   func(rule db.ChannelRule) error
+	remove func(guildID, channelID, module string) (bool, error)
+}
+
+// NewStore creates a store of the channel rules.
+func NewStore() *Store {
+	return &Store{
+		cache:  make(map[string]Rules),
+		load:   db.GetChannelRules,
+		save:   db.SaveChannelRule,
+		remove: db.DeleteChannelRule,
+	}
+}
+
+// Rules returns the channel rules of the guild. Guilds whose rules can't be loaded have none,
+// so the bot keeps answering everywhere.
+func (s *Store) Rules(guildID string) Rules {
+	s.mu.RLock()
+	rules, ok := s.cache[guildID]
+	s.mu.RUnlock()
+	if ok {
+		return rules
+	}
+
+	rules, err := s.fetch(guildID)
+	if err != nil {
+		slog.Errorf("Error loading channel rules of guild %v: %v", guildID, err)
+		return nil
+	}
+
+	s.mu.Lock()
+	s.cache[guildID] = rules
+	s.mu.Unlock()
+	return rules
+}
+
+// Allows reports whether the module answers in the channel of the guild, module "" for the whole bot.
+// Direct messages, with an empty guild ID, are always allowed.
+func (s *Store) Allows(guildID, channelID, module string) bool {
+	if guildID == "" {
+		return true
+	}
+	return s.Rules(guildID).Allows(channelID, module)
+}
+
+// Set saves the rule of the module in the channel of the guild, module "" for the whole bot.
+func (s *Store) Set(guildID string, rule Rule) error {
+	if err := s.save(db.ChannelRule{GuildID: guildID, ChannelID: rule.ChannelID, Module: rule.Module, Allow: rule.Allow}); err != nil {
+		return err
+	}
+	return s.refresh(guildID)
+}
+
+// Reset deletes the rule of the module in the channel of the guild. It reports false when there was none.
+func (s *Store) Reset(guildID, channelID, module string) (bool, error) {
+	existed, err := s.remove(guildID, channelID, module)
+	if err != nil || !existed {
+		return existed, err
+	}
+	return true, s.refresh(guildID)
+}
+
+// refresh reloads the rules of the guild into the cache.
+func (s *Store) refresh(guildID string) error {
+	rules, err := s.fetch(guildID)
+	if err != nil {
+		s.mu.Lock()
+		delete(s.cache, guildID)
+		s.mu.Unlock()
+		return err
+	}
+
+	s.mu.Lock()
+	s.cache[guildID] = rules
+	s.mu.Unlock()
+	return nil
+}
+
+// fetch loads the rules of the guild from the database.
+func (s *Store) fetch(guildID string) (Rules, error) {
+	stored, err := s.load(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(Rules, 0, len(stored))
+	for _, rule := range stored {
+		rules = append(rules, Rule{ChannelID: rule.ChannelID, Module: rule.Module, Allow: rule.Allow})
+	}
+	return rules, nil
+}
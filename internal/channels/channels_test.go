@@ -0,0 +1,141 @@
+package channels
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keshon/dice-roller/internal/db"
+)
+
+// table is an in-memory channel_rules table, in the order the database returns the rules.
+type table struct {
+	rows  []db.ChannelRule
+	loads int
+}
+
+func (tb *table) load(guildID string) ([]db.ChannelRule, error) {
+	tb.loads++
+	var rules []db.ChannelRule
+	for _, row := range tb.rows {
+		if row.GuildID == guildID {
+			rules = append(rules, row)
+		}
+	}
+	return rules, nil
+}
+
+func (tb *table) save(rule db.ChannelRule) error {
+	if i := tb.index(rule.GuildID, rule.ChannelID, rule.Module); i >= 0 {
+		tb.rows[i] = rule
+		return nil
+	}
+	tb.rows = append(tb.rows, rule)
+	return nil
+}
+
+func (tb *table) remove(guildID, channelID, module string) (bool, error) {
+	i := tb.index(guildID, channelID, module)
+	if i < 0 {
+		return false, nil
+	}
+	tb.rows = append(tb.rows[:i], tb.rows[i+1:]...)
+	return true, nil
+}
+
+func (tb *table) index(guildID, channelID, module string) int {
+	for i, row := range tb.rows {
+		if row.GuildID == guildID && row.ChannelID == channelID && row.Module == module {
+			return i
+		}
+	}
+	return -1
+}
+
+// store returns a store reading and writing the table.
+func (tb *table) store() *Store {
+	s := NewStore()
+	s.load, s.save, s.remove = tb.load, tb.save, tb.remove
+	return s
+}
+
+func TestRules(t *testing.T) {
+	t.Run("NoRules", func(t *testing.T) {
+		var rules Rules
+		assert.True(t, rules.Allows("general", ""))
+		assert.True(t, rules.Allows("general", "dicer"))
+	})
+
+	t.Run("DenyList", func(t *testing.T) {
+		rules := Rules{{ChannelID: "general"}}
+		assert.False(t, rules.Allows("general", ""))
+		assert.False(t, rules.Allows("general", "dicer"))
+		assert.True(t, rules.Allows("dice", "dicer"))
+		assert.False(t, rules.AllowList())
+	})
+
+	t.Run("AllowList", func(t *testing.T) {
+		rules := Rules{{ChannelID: "dice", Allow: true}, {ChannelID: "session", Allow: true}}
+		assert.True(t, rules.Allows("dice", "dicer"))
+		assert.True(t, rules.Allows("session", ""))
+		assert.False(t, rules.Allows("general", "dicer"))
+		assert.True(t, rules.AllowList())
+	})
+
+	t.Run("ModuleOverrides", func(t *testing.T) {
+		rules := Rules{
+			{ChannelID: "dice", Allow: true},
+			{ChannelID: "dice", Module: "generator"},
+			{ChannelID: "general", Module: "about", Allow: true},
+		}
+		assert.True(t, rules.Allows("dice", "dicer"))
+		assert.False(t, rules.Allows("dice", "generator"))
+		assert.True(t, rules.Allows("general", "about"))
+		assert.False(t, rules.Allows("general", "dicer"))
+		assert.False(t, rules.Allows("general", ""))
+	})
+}
+
+func TestStore(t *testing.T) {
+	t.Run("Cache", func(t *testing.T) {
+		rules := &table{}
+		s := rules.store()
+		assert.True(t, s.Allows("guild", "general", "dicer"))
+		assert.True(t, s.Allows("guild", "dice", "dicer"))
+		assert.Equal(t, 1, rules.loads)
+		assert.True(t, s.Allows("", "dm", "dicer"))
+		assert.Equal(t, 1, rules.loads, "direct messages have no rules")
+	})
+
+	t.Run("SetReset", func(t *testing.T) {
+		rules := &table{}
+		s := rules.store()
+		s.Rules("guild")
+
+		assert.NoError(t, s.Set("guild", Rule{ChannelID: "dice", Allow: true}))
+		assert.False(t, s.Allows("guild", "general", "dicer"))
+		assert.True(t, s.Allows("other", "general", "dicer"), "other guilds are not changed")
+		assert.Len(t, rules.rows, 1)
+
+		assert.NoError(t, s.Set("guild", Rule{ChannelID: "dice", Module: "generator"}))
+		assert.False(t, s.Allows("guild", "dice", "generator"))
+		assert.Equal(t, Rules{{ChannelID: "dice", Allow: true}, {ChannelID: "dice", Module: "generator"}}, s.Rules("guild"))
+
+		existed, err := s.Reset("guild", "dice", "")
+		assert.NoError(t, err)
+		assert.True(t, existed)
+		assert.True(t, s.Allows("guild", "general", "dicer"))
+
+		existed, err = s.Reset("guild", "dice", "")
+		assert.NoError(t, err)
+		assert.False(t, existed)
+	})
+
+	t.Run("LoadError", func(t *testing.T) {
+		s := (&table{}).store()
+		s.load = func(guildID string) ([]db.ChannelRule, error) { return nil, errors.New("database locked") }
+		assert.True(t, s.Allows("guild", "general", "dicer"))
+		assert.Error(t, s.Set("guild", Rule{ChannelID: "general"}))
+	})
+}
@@ -0,0 +1,37 @@
+package db
+
+// ChannelRule is whether the bot answers in a channel of a guild. An empty Module is the rule of the whole bot,
+// otherwise the rule overrides it for that module.
+type ChannelRule struct {
+	GuildID   string `gorm:"primaryKey"`
+	ChannelID string `gorm:"primaryKey"`
+	Module    string `gorm:"primaryKey"`
+	Allow     bool
+}
+
+// GetChannelRules retrieves the channel rules of a guild.
+//
+// guildID string
+// []ChannelRule, error
+func GetChannelRules(guildID string) ([]ChannelRule, error) {
+	var rules []ChannelRule
+	err := DB.Where("guild_id = ?", guildID).Order("channel_id, module").Find(&rules).Error
+	return rules, err
+}
+
+// SaveChannelRule creates or updates a channel rule.
+//
+// rule: the channel rule to be saved.
+// error: an error if the saving fails.
+func SaveChannelRule(rule ChannelRule) error {
+	return DB.Save(&rule).Error
+}
+
+// DeleteChannelRule deletes the rule of a module in a channel, the rule of the whole bot for an empty module.
+//
+// Parameters: guildID, channelID, module string
+// Return type: bool reporting whether the rule existed, error
+func DeleteChannelRule(guildID, channelID, module string) (bool, error) {
+	result := DB.Where("guild_id = ? AND channel_id = ? AND module = ?", guildID, channelID, module).Delete(&ChannelRule{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+// ChaosFactor is the Mythic chaos factor of the story played in a channel.
+type ChaosFactor struct {
+	ChannelID string `gorm:"primaryKey"`
+	Value     int
+}
+
+// GetChaosFactor retrieves the chaos factor of a channel.
+//
+// channelID string
+// *ChaosFactor, error
+func GetChaosFactor(channelID string) (*ChaosFactor, error) {
+	var chaos ChaosFactor
+	err := DB.Where("channel_id = ?", channelID).First(&chaos).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &chaos, err
+}
+
+// SaveChaosFactor creates or updates the chaos factor of a channel.
+//
+// chaos: the chaos factor to be saved.
+// error: an error if the saving fails.
+func SaveChaosFactor(chaos ChaosFactor) error {
+	return DB.Save(&chaos).Error
+}
@@ -21,7 +21,7 @@ func InitDB(databasePath string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	err = DB.AutoMigrate(&Guild{})
+	err = DB.AutoMigrate(&Guild{}, &UserSettings{}, &MoveLabels{}, &CustomDie{}, &ProgressTrack{}, &RandomTable{}, &CustomGenerator{}, &ChaosFactor{}, &ChannelDeck{}, &GuildModule{}, &GuildSettings{}, &ChannelRule{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to auto migrate tables: %w", err)
 	}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+// ChannelDeck is the deck of cards of a channel. State holds the JSON of the draw pile, the cards in play and the discards.
+type ChannelDeck struct {
+	ChannelID string `gorm:"primaryKey"`
+	State     string
+}
+
+// GetChannelDeck retrieves the deck of a channel.
+//
+// channelID string
+// *ChannelDeck, error
+func GetChannelDeck(channelID string) (*ChannelDeck, error) {
+	var deck ChannelDeck
+	err := DB.Where("channel_id = ?", channelID).First(&deck).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &deck, err
+}
+
+// SaveChannelDeck creates or updates the deck of a channel.
+//
+// deck: the deck to be saved.
+// error: an error if the saving fails.
+func SaveChannelDeck(deck ChannelDeck) error {
+	return DB.Save(&deck).Error
+}
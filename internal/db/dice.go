@@ -0,0 +1,53 @@
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+// CustomDie is a user-defined die with its face list, e.g. "[copper, silver:2, gold]".
+// ScopeID is a guild ID, or a user ID for direct messages.
+type CustomDie struct {
+	ScopeID string `gorm:"primaryKey"`
+	Name    string `gorm:"primaryKey"`
+	Faces   string
+}
+
+// GetCustomDie retrieves a custom die by its name.
+//
+// scopeID, name string
+// *CustomDie, error
+func GetCustomDie(scopeID, name string) (*CustomDie, error) {
+	var die CustomDie
+	err := DB.Where("scope_id = ? AND name = ?", scopeID, name).First(&die).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &die, err
+}
+
+// GetCustomDice retrieves all custom dice of a guild or user ordered by name.
+//
+// scopeID string
+// []CustomDie, error
+func GetCustomDice(scopeID string) ([]CustomDie, error) {
+	var dice []CustomDie
+	err := DB.Where("scope_id = ?", scopeID).Order("name").Find(&dice).Error
+	return dice, err
+}
+
+// SaveCustomDie creates or replaces a custom die.
+//
+// die: the die to be saved.
+// error: an error if the saving fails.
+func SaveCustomDie(die CustomDie) error {
+	return DB.Save(&die).Error
+}
+
+// DeleteCustomDie deletes a custom die by its name.
+//
+// Parameters: scopeID, name string
+// Return type: bool reporting whether the die existed, error
+func DeleteCustomDie(scopeID, name string) (bool, error) {
+	result := DB.Where("scope_id = ? AND name = ?", scopeID, name).Delete(&CustomDie{})
+	return result.RowsAffected > 0, result.Error
+}
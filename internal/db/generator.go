@@ -0,0 +1,53 @@
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+// CustomGenerator is a generator definition uploaded by a guild, either a new generator
+// or an extension of a built-in one. Definition holds the JSON of the generator.
+type CustomGenerator struct {
+	GuildID    string `gorm:"primaryKey"`
+	Name       string `gorm:"primaryKey"`
+	Definition string
+}
+
+// GetCustomGenerator retrieves a custom generator by its name.
+//
+// guildID, name string
+// *CustomGenerator, error
+func GetCustomGenerator(guildID, name string) (*CustomGenerator, error) {
+	var generator CustomGenerator
+	err := DB.Where("guild_id = ? AND name = ?", guildID, name).First(&generator).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &generator, err
+}
+
+// GetCustomGenerators retrieves all custom generators of a guild ordered by name.
+//
+// guildID string
+// []CustomGenerator, error
+func GetCustomGenerators(guildID string) ([]CustomGenerator, error) {
+	var generators []CustomGenerator
+	err := DB.Where("guild_id = ?", guildID).Order("name").Find(&generators).Error
+	return generators, err
+}
+
+// SaveCustomGenerator creates or replaces a custom generator.
+//
+// generator: the generator to be saved.
+// error: an error if the saving fails.
+func SaveCustomGenerator(generator CustomGenerator) error {
+	return DB.Save(&generator).Error
+}
+
+// DeleteCustomGenerator deletes a custom generator by its name.
+//
+// Parameters: guildID, name string
+// Return type: bool reporting whether the generator existed, error
+func DeleteCustomGenerator(guildID, name string) (bool, error) {
+	result := DB.Where("guild_id = ? AND name = ?", guildID, name).Delete(&CustomGenerator{})
+	return result.RowsAffected > 0, result.Error
+}
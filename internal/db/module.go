@@ -0,0 +1,26 @@
+package db
+
+// GuildModule is whether a module is enabled in a guild. Modules without a row use their default.
+type GuildModule struct {
+	GuildID string `gorm:"primaryKey"`
+	Module  string `gorm:"primaryKey"`
+	Enabled bool
+}
+
+// GetGuildModules retrieves the module settings of a guild.
+//
+// guildID string
+// []GuildModule, error
+func GetGuildModules(guildID string) ([]GuildModule, error) {
+	var modules []GuildModule
+	err := DB.Where("guild_id = ?", guildID).Order("module").Find(&modules).Error
+	return modules, err
+}
+
+// SaveGuildModule creates or updates the setting of a module in a guild.
+//
+// module: the module setting to be saved.
+// error: an error if the saving fails.
+func SaveGuildModule(module GuildModule) error {
+	return DB.Save(&module).Error
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+// MoveLabels holds the outcome band labels of Powered by the Apocalypse moves.
+// ScopeID is a guild ID, or a user ID for direct messages.
+type MoveLabels struct {
+	ScopeID   string `gorm:"primaryKey"`
+	StrongHit string
+	WeakHit   string
+	Miss      string
+}
+
+// GetMoveLabels retrieves the move labels of a guild or user.
+//
+// scopeID string
+// *MoveLabels, error
+func GetMoveLabels(scopeID string) (*MoveLabels, error) {
+	var labels MoveLabels
+	err := DB.Where("scope_id = ?", scopeID).First(&labels).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &labels, err
+}
+
+// SaveMoveLabels creates or updates the move labels of a guild or user.
+//
+// labels: the labels to be saved.
+// error: an error if the saving fails.
+func SaveMoveLabels(labels MoveLabels) error {
+	return DB.Save(&labels).Error
+}
+
+// DeleteMoveLabels deletes the move labels of a guild or user.
+//
+// Parameter: scopeID string
+// Return type: error
+func DeleteMoveLabels(scopeID string) error {
+	return DB.Where("scope_id = ?", scopeID).Delete(&MoveLabels{}).Error
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+// ProgressTrack is an Ironsworn progress track of a user, such as a vow or a journey.
+type ProgressTrack struct {
+	UserID string `gorm:"primaryKey"`
+	Name   string `gorm:"primaryKey"`
+	Rank   string
+	Ticks  int
+}
+
+// GetProgressTrack retrieves a progress track of a user by its name.
+//
+// userID, name string
+// *ProgressTrack, error
+func GetProgressTrack(userID, name string) (*ProgressTrack, error) {
+	var track ProgressTrack
+	err := DB.Where("user_id = ? AND name = ?", userID, name).First(&track).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &track, err
+}
+
+// GetProgressTracks retrieves all progress tracks of a user ordered by name.
+//
+// userID string
+// []ProgressTrack, error
+func GetProgressTracks(userID string) ([]ProgressTrack, error) {
+	var tracks []ProgressTrack
+	err := DB.Where("user_id = ?", userID).Order("name").Find(&tracks).Error
+	return tracks, err
+}
+
+// SaveProgressTrack creates or updates a progress track.
+//
+// track: the track to be saved.
+// error: an error if the saving fails.
+func SaveProgressTrack(track ProgressTrack) error {
+	return DB.Save(&track).Error
+}
+
+// DeleteProgressTrack deletes a progress track of a user by its name.
+//
+// Parameters: userID, name string
+// Return type: bool reporting whether the track existed, error
+func DeleteProgressTrack(userID, name string) (bool, error) {
+	result := DB.Where("user_id = ? AND name = ?", userID, name).Delete(&ProgressTrack{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+// GuildSettings are the settings of a guild. Empty values use the bot defaults.
+type GuildSettings struct {
+	GuildID     string `gorm:"primaryKey"`
+	Prefix      string
+	Locale      string
+	EmbedColor  int
+	DefaultRoll string
+	// AdminRoles are the comma-separated IDs of the roles allowed to use the commands needing a permission.
+	AdminRoles string
+	// UserLimit, ChannelLimit and GuildLimit are the rate limits of the commands, e.g. "5/10s" or "off".
+	UserLimit    string
+	ChannelLimit string
+	GuildLimit   string
+}
+
+// GetGuildSettings retrieves the settings of a guild by its ID.
+//
+// guildID string
+// *GuildSettings, error
+func GetGuildSettings(guildID string) (*GuildSettings, error) {
+	var settings GuildSettings
+	err := DB.Where("guild_id = ?", guildID).First(&settings).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &settings, err
+}
+
+// SaveGuildSettings creates or updates the settings of a guild.
+//
+// settings: the settings to be saved.
+// error: an error if the saving fails.
+func SaveGuildSettings(settings GuildSettings) error {
+	return DB.Save(&settings).Error
+}
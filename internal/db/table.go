@@ -0,0 +1,53 @@
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+// RandomTable is a random table uploaded by a GM, stored as "1-3 | Goblins" rows.
+// ScopeID is a guild ID, or a user ID for direct messages.
+type RandomTable struct {
+	ScopeID string `gorm:"primaryKey"`
+	Name    string `gorm:"primaryKey"`
+	Rows    string
+}
+
+// GetRandomTable retrieves a random table by its name.
+//
+// scopeID, name string
+// *RandomTable, error
+func GetRandomTable(scopeID, name string) (*RandomTable, error) {
+	var table RandomTable
+	err := DB.Where("scope_id = ? AND name = ?", scopeID, name).First(&table).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &table, err
+}
+
+// GetRandomTables retrieves all random tables of a guild or user ordered by name.
+//
+// scopeID string
+// []RandomTable, error
+func GetRandomTables(scopeID string) ([]RandomTable, error) {
+	var tables []RandomTable
+	err := DB.Where("scope_id = ?", scopeID).Order("name").Find(&tables).Error
+	return tables, err
+}
+
+// SaveRandomTable creates or replaces a random table.
+//
+// table: the table to be saved.
+// error: an error if the saving fails.
+func SaveRandomTable(table RandomTable) error {
+	return DB.Save(&table).Error
+}
+
+// DeleteRandomTable deletes a random table by its name.
+//
+// Parameters: scopeID, name string
+// Return type: bool reporting whether the table existed, error
+func DeleteRandomTable(scopeID, name string) (bool, error) {
+	result := DB.Where("scope_id = ? AND name = ?", scopeID, name).Delete(&RandomTable{})
+	return result.RowsAffected > 0, result.Error
+}
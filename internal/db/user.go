@@ -0,0 +1,31 @@
+package db
+
+import (
+	"gorm.io/gorm"
+)
+
+type UserSettings struct {
+	UserID      string `gorm:"primaryKey"`
+	DefaultRoll string
+}
+
+// GetUserSettings retrieves the settings of a user by their ID.
+//
+// userID string
+// *UserSettings, error
+func GetUserSettings(userID string) (*UserSettings, error) {
+	var settings UserSettings
+	err := DB.Where("user_id = ?", userID).First(&settings).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &settings, err
+}
+
+// SaveUserSettings creates or updates the settings of a user.
+//
+// settings: the settings to be saved.
+// error: an error if the saving fails.
+func SaveUserSettings(settings UserSettings) error {
+	return DB.Save(&settings).Error
+}
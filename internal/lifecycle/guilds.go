@@ -0,0 +1,88 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+)
+
+// Guild is a guild served by a module: its lifecycle and the state the module keeps for it.
+type Guild[T any] struct {
+	Lifecycle
+	ID   string
+	Data T
+}
+
+// Guilds tracks the guilds a module serves, so one module instance and one set of handlers serve every guild.
+// Handlers look up the guild of each event, which costs the same however many guilds there are.
+// It is safe for concurrent use.
+type Guilds[T any] struct {
+	mu      sync.RWMutex
+	guilds  map[string]*Guild[T]
+	newData func(guildID string) T
+}
+
+// NewGuilds creates the guilds of a module. newData creates the state of a guild when it first starts,
+// it is kept when the guild stops and starts again.
+func NewGuilds[T any](newData func(guildID string) T) *Guilds[T] {
+	return &Guilds[T]{
+		guilds:  make(map[string]*Guild[T]),
+		newData: newData,
+	}
+}
+
+// Start starts serving the guild, until Stop is called or the context is done.
+func (g *Guilds[T]) Start(ctx context.Context, guildID string) (*Guild[T], error) {
+	g.mu.Lock()
+	guild, ok := g.guilds[guildID]
+	if !ok {
+		guild = &Guild[T]{ID: guildID}
+		if g.newData != nil {
+			guild.Data = g.newData(guildID)
+		}
+		g.guilds[guildID] = guild
+	}
+	g.mu.Unlock()
+
+	if _, err := guild.Begin(ctx); err != nil {
+		return nil, err
+	}
+	return guild, nil
+}
+
+// Stop stops serving the guild.
+func (g *Guilds[T]) Stop(guildID string) {
+	if guild, ok := g.lookup(guildID); ok {
+		guild.End()
+	}
+}
+
+// Get returns the guild when it is served.
+func (g *Guilds[T]) Get(guildID string) (*Guild[T], bool) {
+	guild, ok := g.lookup(guildID)
+	if !ok || !guild.Running() {
+		return nil, false
+	}
+	return guild, true
+}
+
+// Serves reports whether the guild is served.
+func (g *Guilds[T]) Serves(guildID string) bool {
+	_, ok := g.Get(guildID)
+	return ok
+}
+
+// State returns the state of the module in the guild, stopped when it never started there.
+func (g *Guilds[T]) State(guildID string) State {
+	if guild, ok := g.lookup(guildID); ok {
+		return guild.State()
+	}
+	return Stopped
+}
+
+// lookup returns the guild whatever its state.
+func (g *Guilds[T]) lookup(guildID string) (*Guild[T], bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	guild, ok := g.guilds[guildID]
+	return guild, ok
+}
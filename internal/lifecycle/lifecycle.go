@@ -0,0 +1,111 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// State is the state of a module instance.
+type State string
+
+const (
+	Stopped State = "stopped"
+	Running State = "running"
+	Error   State = "error"
+)
+
+// Lifecycle tracks the state of a module instance and the cleanups to run when it stops,
+// such as removing its session handlers. The zero value is a stopped lifecycle ready to start.
+type Lifecycle struct {
+	mu       sync.Mutex
+	state    State
+	err      error
+	run      int
+	cancel   context.CancelFunc
+	cleanups []func()
+}
+
+// Begin moves the lifecycle to running and returns a context that is done when it stops.
+// The lifecycle stops on its own when the parent context is done.
+func (l *Lifecycle) Begin(parent context.Context) (context.Context, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.state == Running {
+		return nil, fmt.Errorf("already running")
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	l.state, l.err, l.cancel = Running, nil, cancel
+	l.run++
+	run := l.run
+
+	go func() {
+		<-ctx.Done()
+		l.stop(run, Stopped, nil)
+	}()
+
+	return ctx, nil
+}
+
+// Track adds a cleanup to run when the lifecycle stops, e.g. the removal func returned by Session.AddHandler.
+// Cleanups run in reverse order.
+func (l *Lifecycle) Track(cleanup func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cleanups = append(l.cleanups, cleanup)
+}
+
+// Fail runs the cleanups and moves the lifecycle to the error state. It returns the error.
+func (l *Lifecycle) Fail(err error) error {
+	l.stop(0, Error, err)
+	return err
+}
+
+// End runs the cleanups and moves the lifecycle to stopped, unless it is already stopped or failed.
+func (l *Lifecycle) End() {
+	l.stop(0, Stopped, nil)
+}
+
+// stop runs the cleanups once and moves the lifecycle to the given state.
+// A run other than 0 only stops that run, not a later restart.
+func (l *Lifecycle) stop(run int, state State, err error) {
+	l.mu.Lock()
+	if l.state != Running || (run != 0 && run != l.run) {
+		l.mu.Unlock()
+		return
+	}
+	cleanups, cancel := l.cleanups, l.cancel
+	l.state, l.err, l.cleanups, l.cancel = state, err, nil, nil
+	l.mu.Unlock()
+
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// State returns the state of the lifecycle.
+func (l *Lifecycle) State() State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.state == "" {
+		return Stopped
+	}
+	return l.state
+}
+
+// Running reports whether the lifecycle is running.
+func (l *Lifecycle) Running() bool {
+	return l.State() == Running
+}
+
+// Err returns the error the lifecycle failed with, nil unless it is in the error state.
+func (l *Lifecycle) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
@@ -0,0 +1,142 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycle(t *testing.T) {
+	t.Run("ZeroValue", func(t *testing.T) {
+		var l Lifecycle
+		assert.Equal(t, Stopped, l.State())
+		assert.False(t, l.Running())
+		assert.NoError(t, l.Err())
+	})
+
+	t.Run("CleanupsRunOnceInReverse", func(t *testing.T) {
+		var l Lifecycle
+		_, err := l.Begin(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, l.Running())
+
+		var order []int
+		l.Track(func() { order = append(order, 1) })
+		l.Track(func() { order = append(order, 2) })
+
+		l.End()
+		l.End()
+		assert.Equal(t, []int{2, 1}, order)
+		assert.Equal(t, Stopped, l.State())
+	})
+
+	t.Run("AlreadyRunning", func(t *testing.T) {
+		var l Lifecycle
+		_, err := l.Begin(context.Background())
+		assert.NoError(t, err)
+		_, err = l.Begin(context.Background())
+		assert.EqualError(t, err, "already running")
+		l.End()
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		var l Lifecycle
+		removed := 0
+		for i := 0; i < 3; i++ {
+			ctx, err := l.Begin(context.Background())
+			assert.NoError(t, err)
+			l.Track(func() { removed++ })
+			l.End()
+			<-ctx.Done()
+		}
+		assert.Equal(t, 3, removed)
+
+		// the previous run ending late doesn't stop a restart
+		_, err := l.Begin(context.Background())
+		assert.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+		assert.True(t, l.Running())
+		l.End()
+		assert.Equal(t, Stopped, l.State())
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		var l Lifecycle
+		_, err := l.Begin(context.Background())
+		assert.NoError(t, err)
+		removed := false
+		l.Track(func() { removed = true })
+
+		err = l.Fail(errors.New("no generators"))
+		assert.EqualError(t, err, "no generators")
+		assert.True(t, removed)
+		assert.Equal(t, Error, l.State())
+		assert.EqualError(t, l.Err(), "no generators")
+
+		_, err = l.Begin(context.Background())
+		assert.NoError(t, err, "a failed lifecycle can start again")
+		assert.NoError(t, l.Err())
+		l.End()
+	})
+
+	t.Run("ParentDone", func(t *testing.T) {
+		var l Lifecycle
+		parent, cancel := context.WithCancel(context.Background())
+		_, err := l.Begin(parent)
+		assert.NoError(t, err)
+
+		cancel()
+		assert.Eventually(t, func() bool { return l.State() == Stopped }, time.Second, time.Millisecond)
+	})
+}
+
+func TestGuilds(t *testing.T) {
+	t.Run("StartStop", func(t *testing.T) {
+		created := 0
+		guilds := NewGuilds(func(guildID string) string {
+			created++
+			return "data of " + guildID
+		})
+		assert.Equal(t, Stopped, guilds.State("guild"))
+
+		guild, err := guilds.Start(context.Background(), "guild")
+		assert.NoError(t, err)
+		assert.Equal(t, "data of guild", guild.Data)
+		assert.True(t, guilds.Serves("guild"))
+		assert.False(t, guilds.Serves("other"))
+
+		_, err = guilds.Start(context.Background(), "guild")
+		assert.EqualError(t, err, "already running")
+
+		guilds.Stop("guild")
+		assert.Equal(t, Stopped, guilds.State("guild"))
+		_, ok := guilds.Get("guild")
+		assert.False(t, ok)
+
+		_, err = guilds.Start(context.Background(), "guild")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, created, "the data is kept across restarts")
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		guilds := NewGuilds[struct{}](nil)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			guildID := fmt.Sprint(i % 10)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				guilds.Start(context.Background(), guildID)
+				guilds.Serves(guildID)
+				guilds.State(guildID)
+				guilds.Stop(guildID)
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,132 @@
+package manager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/botsdef"
+	"github.com/keshon/dice-roller/internal/channels"
+	"github.com/keshon/dice-roller/internal/router"
+	"github.com/keshon/dice-roller/internal/version"
+)
+
+var channelPattern = regexp.MustCompile(`^<#(\d+)>$`)
+
+// handleChannelCommand lists the channel rules of the guild, or allows, denies or resets a channel,
+// e.g. "channel allow #dice" or "channel deny #general generator". The channel defaults to the current one.
+func (gm *GuildManager) handleChannelCommand(ctx *router.Context) {
+	if gm.Router.Channels == nil {
+		ctx.Reply("Error: channel rules are not available")
+		return
+	}
+
+	fields := strings.Fields(ctx.Raw)
+	if len(fields) == 0 {
+		gm.listChannels(ctx)
+		return
+	}
+
+	action := strings.ToLower(fields[0])
+	if action != "allow" && action != "deny" && action != "reset" {
+		ctx.Reply(fmt.Sprintf("Error: use `%schannel allow|deny|reset [#channel] [module]`", ctx.Prefix))
+		return
+	}
+
+	rule := channels.Rule{ChannelID: ctx.Message.ChannelID, Allow: action == "allow"}
+	for _, field := range fields[1:] {
+		if match := channelPattern.FindStringSubmatch(field); match != nil {
+			rule.ChannelID = match[1]
+			continue
+		}
+		p, ok := botsdef.Lookup(strings.ToLower(field))
+		if !ok {
+			ctx.Reply(fmt.Sprintf("Error: `%s` is neither a channel nor a module, see `%smodules`", field, ctx.Prefix))
+			return
+		}
+		rule.Module = p.Name
+	}
+
+	guildID := ctx.Message.GuildID
+	if action == "reset" {
+		existed, err := gm.Router.Channels.Reset(guildID, rule.ChannelID, rule.Module)
+		if err != nil {
+			slog.Errorf("Error resetting channel %v of guild %v: %v", rule.ChannelID, guildID, err)
+			ctx.Reply("Error resetting channel")
+			return
+		}
+		if !existed {
+			ctx.Reply(fmt.Sprintf("Error: %s has no rule", ruleName(rule)))
+			return
+		}
+		ctx.Reply(fmt.Sprintf("Rule of %s removed", ruleName(rule)))
+		return
+	}
+
+	if err := gm.Router.Channels.Set(guildID, rule); err != nil {
+		slog.Errorf("Error saving channel %v of guild %v: %v", rule.ChannelID, guildID, err)
+		ctx.Reply("Error saving channel")
+		return
+	}
+
+	reply := fmt.Sprintf("%s ignores <#%s>", subject(rule), rule.ChannelID)
+	if rule.Allow {
+		reply = fmt.Sprintf("%s answers in <#%s>", subject(rule), rule.ChannelID)
+		if rule.Module == "" {
+			reply += ", and only in the allowed channels"
+		}
+	}
+	ctx.Reply(reply)
+}
+
+// listChannels sends the channel rules of the guild.
+func (gm *GuildManager) listChannels(ctx *router.Context) {
+	rules := gm.Router.Channels.Rules(ctx.Message.GuildID)
+
+	description := "The bot answers in every channel."
+	switch {
+	case rules.AllowList():
+		description = "The bot answers in the allowed channels only."
+	case len(rules) > 0:
+		description = "The bot answers in every channel but the denied ones."
+	}
+	description += " Administration commands answer everywhere."
+
+	var lines []string
+	for _, rule := range rules {
+		state := "denied"
+		if rule.Allow {
+			state = "allowed"
+		}
+		lines = append(lines, fmt.Sprintf("%s - %s", ruleName(rule), state))
+	}
+	if len(lines) > 0 {
+		description += "\n\n" + strings.Join(lines, "\n")
+	}
+
+	ctx.Session.ChannelMessageSendEmbed(ctx.Message.ChannelID, &discordgo.MessageEmbed{
+		Title:       "📺 Channels",
+		Description: description,
+		Color:       ctx.Settings.EmbedColor,
+		Footer:      &discordgo.MessageEmbedFooter{Text: version.AppFullName},
+	})
+}
+
+// subject returns what a rule applies to, the bot or one of its modules.
+func subject(rule channels.Rule) string {
+	if rule.Module == "" {
+		return "The bot"
+	}
+	return fmt.Sprintf("Module `%s`", rule.Module)
+}
+
+// ruleName returns the channel of a rule, with its module when it overrides the channel rule.
+func ruleName(rule channels.Rule) string {
+	if rule.Module == "" {
+		return fmt.Sprintf("<#%s>", rule.ChannelID)
+	}
+	return fmt.Sprintf("`%s` in <#%s>", rule.Module, rule.ChannelID)
+}
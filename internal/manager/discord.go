@@ -1,81 +1,152 @@
 package manager
 
 import (
-	"fmt"
-	"strings"
+	"context"
+	"sync"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/gookit/slog"
 
 	"github.com/keshon/dice-roller/internal/botsdef"
-	"github.com/keshon/dice-roller/internal/config"
 	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/internal/lifecycle"
+	"github.com/keshon/dice-roller/internal/router"
 )
 
 type GuildManager struct {
-	Session       *discordgo.Session
-	Bots          map[string]map[string]botsdef.Discord
-	commandPrefix string
+	Session *discordgo.Session
+	Guilds  *botsdef.GuildRegistry
+	Modules map[string]botsdef.Discord // the instance of each module, shared by the guilds it serves
+	Router  *router.Router
+	ctx     context.Context
+	plugins []botsdef.Plugin
+	// mu serializes registering, unregistering and setting up guilds, so a module can't be left
+	// running for a guild unregistered by another event at the same time.
+	mu sync.Mutex
 }
 
-// NewGuildManager creates a new GuildManager with the given discord session and bot instances.
+// NewGuildManager creates a new GuildManager with the given discord session and guild registry.
 //
 // Parameters:
 // - session: *discordgo.Session
-// - botInstances: map[string]*discord.BotInstance
+// - r: the command router
+// - guilds: the registry of the registered guilds and their modules
 // Return type: *GuildManager
-func NewGuildManager(session *discordgo.Session, bots map[string]map[string]botsdef.Discord) *GuildManager {
-	config, err := config.NewConfig()
-	if err != nil {
-		slog.Fatalf("Error loading config:", err)
-	}
-
+func NewGuildManager(session *discordgo.Session, r *router.Router, guilds *botsdef.GuildRegistry) *GuildManager {
 	return &GuildManager{
-		Session:       session,
-		Bots:          bots,
-		commandPrefix: config.DiscordCommandPrefix,
+		Session: session,
+		Guilds:  guilds,
+		Router:  r,
 	}
 }
 
-// Start starts the GuildManager.
-func (gm *GuildManager) Start() {
+// Start starts the GuildManager: it creates the instance of each module, serves direct messages with
+// the modules that support them and starts the enabled modules in every registered guild.
+// Module instances it starts stop when the context is done.
+// It fails when the registered modules or the guilds can't be loaded.
+func (gm *GuildManager) Start(ctx context.Context) error {
 	slog.Info("Discord instance of guild manager started")
-	gm.Session.AddHandler(gm.Commands)
-}
 
-// Commands handles the commands received in a Discord session message.
-//
-// Parameters:
-//   - s: a pointer to the Discord session
-//   - m: a pointer to the Discord message received
-func (gm *GuildManager) Commands(s *discordgo.Session, m *discordgo.MessageCreate) {
-	command, _, err := parseCommand(m.Message.Content, gm.commandPrefix)
+	plugins, err := botsdef.Plugins()
 	if err != nil {
-		slog.Error(err)
-		return
+		return err
 	}
 
-	switch command {
-	case "about", "v", "help", "h", "roll":
-		guildID := m.GuildID
-		exists, err := db.DoesGuildExist(guildID)
-		if err != nil {
-			slog.Errorf("Error checking if guild is registered: %v", err)
-			return
-		}
+	gm.ctx = ctx
+	gm.plugins = plugins
+	gm.Router.Mount(router.Global, router.Module{Name: "manager", Commands: gm.commands(), Unrestricted: true})
+	gm.Router.Unmounted = gm.handleUnmounted
 
-		if !exists {
-			gm.Session.ChannelMessageSend(m.Message.ChannelID, "Guild must be registered first.\nUse `"+gm.commandPrefix+"register` command.")
-			return
+	gm.Modules = make(map[string]botsdef.Discord, len(plugins))
+	for _, p := range plugins {
+		gm.Modules[p.Name] = p.New(gm.Session, gm.Router)
+		if !p.Direct {
+			continue
+		}
+		if err := gm.Modules[p.Name].Start(ctx, router.Direct); err != nil {
+			slog.Errorf("Error starting module %v for direct messages: %v", p.Name, err)
 		}
 	}
 
-	switch command {
-	case "register":
-		gm.handleRegisterCommand(s, m)
-	case "unregister":
-		gm.handleUnregisterCommand(s, m)
+	guildIDs, err := db.GetAllGuildIDs()
+	if err != nil {
+		return err
+	}
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	for _, id := range guildIDs {
+		gm.Guilds.Register(id)
+		gm.setupBotInstance(id)
+	}
+
+	return nil
+}
+
+// commands returns the guild administration commands.
+func (gm *GuildManager) commands() []*router.Command {
+	return []*router.Command{
+		{
+			Name:        "register",
+			Permission:  discordgo.PermissionManageServer,
+			GuildOnly:   true,
+			Category:    router.CategoryAdministration,
+			Description: "Enable commands listening in this guild",
+			Handler:     func(ctx *router.Context) { gm.handleRegisterCommand(ctx.Session, ctx.Message) },
+		},
+		{
+			Name:        "unregister",
+			Permission:  discordgo.PermissionManageServer,
+			GuildOnly:   true,
+			Category:    router.CategoryAdministration,
+			Description: "Disable commands listening in this guild",
+			Handler:     func(ctx *router.Context) { gm.handleUnregisterCommand(ctx.Session, ctx.Message) },
+		},
+		{
+			Name: "module", Aliases: []string{"modules"},
+			Args:        []router.Arg{{Name: "enable|disable", Optional: true}, {Name: "module", Optional: true}},
+			Permission:  discordgo.PermissionManageServer,
+			GuildOnly:   true,
+			Category:    router.CategoryAdministration,
+			Description: "List the modules, or enable or disable one in this guild",
+			Examples:    []string{"modules", "module disable generator", "module enable generator"},
+			Handler:     gm.handleModuleCommand,
+		},
+		{
+			Name:        "settings",
+			Args:        []router.Arg{{Name: "setting", Optional: true}, {Name: "value", Optional: true, Variadic: true}},
+			Permission:  discordgo.PermissionManageServer,
+			GuildOnly:   true,
+			Category:    router.CategoryAdministration,
+			Description: "Show the settings of this guild, or change its prefix, locale, embed color, default roll, admin roles or rate limits",
+			Examples:    []string{"settings", "settings prefix !", "settings color #ff8800", "settings roll 2d6", "settings roles @GM", "settings user-limit 3/10s", "settings prefix reset"},
+			Handler:     gm.handleSettingsCommand,
+		},
+		{
+			Name: "channel", Aliases: []string{"channels"},
+			Args:        []router.Arg{{Name: "allow|deny|reset", Optional: true}, {Name: "#channel", Optional: true}, {Name: "module", Optional: true}},
+			Permission:  discordgo.PermissionManageServer,
+			GuildOnly:   true,
+			Category:    router.CategoryAdministration,
+			Description: "List the channel rules, or choose the channels the bot or one of its modules answers in",
+			Examples:    []string{"channels", "channel allow #dice", "channel deny #general", "channel deny #dice generator", "channel reset #general"},
+			Handler:     gm.handleChannelCommand,
+		},
+	}
+}
+
+// handleUnmounted replies to commands sent in guilds that are not registered, or have every module disabled.
+func (gm *GuildManager) handleUnmounted(ctx *router.Context) {
+	exists, err := db.DoesGuildExist(ctx.Message.GuildID)
+	if err != nil {
+		slog.Errorf("Error checking if guild is registered: %v", err)
+		return
+	}
+
+	if exists {
+		ctx.Reply("Every module is disabled in this guild.\nUse `" + ctx.Prefix + "module enable <module>` command.")
+		return
 	}
+	ctx.Reply("Guild must be registered first.\nUse `" + ctx.Prefix + "register` command.")
 }
 
 // handleRegisterCommand handles the registration command for the GuildManager.
@@ -87,6 +158,9 @@ func (gm *GuildManager) handleRegisterCommand(s *discordgo.Session, m *discordgo
 	channelID := m.Message.ChannelID
 	guildID := m.GuildID
 
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
 	exists, err := db.DoesGuildExist(guildID)
 	if err != nil {
 		slog.Errorf("Error checking if guild is registered: %v", err)
@@ -106,7 +180,8 @@ func (gm *GuildManager) handleRegisterCommand(s *discordgo.Session, m *discordgo
 		return
 	}
 
-	gm.setupBotInstance(s, guildID)
+	gm.Guilds.Register(guildID)
+	gm.setupBotInstance(guildID)
 	gm.Session.ChannelMessageSend(channelID, "Guild registered successfully")
 }
 
@@ -120,6 +195,9 @@ func (gm *GuildManager) handleUnregisterCommand(s *discordgo.Session, m *discord
 	channelID := m.Message.ChannelID
 	guildID := m.GuildID
 
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
 	exists, err := db.DoesGuildExist(guildID)
 	if err != nil {
 		slog.Errorf("Error checking if guild is registered: %v", err)
@@ -142,76 +220,65 @@ func (gm *GuildManager) handleUnregisterCommand(s *discordgo.Session, m *discord
 	gm.Session.ChannelMessageSend(channelID, "Guild unregistered successfully")
 }
 
-// setupBotInstance sets up a bot instance for the given guild.
+// setupBotInstance starts the enabled modules in the given guild, restarting stopped ones,
+// and stops the disabled modules. The caller holds gm.mu.
 //
 // Parameters:
-// - session: pointer to discordgo.Session
 // - guildID: string
-func (gm *GuildManager) setupBotInstance(session *discordgo.Session, guildID string) {
-	id := guildID
-
-	if _, ok := gm.Bots[id]; !ok {
-		gm.Bots[id] = make(map[string]botsdef.Discord)
+func (gm *GuildManager) setupBotInstance(guildID string) {
+	enabled, err := botsdef.EnabledModules(gm.plugins, guildID)
+	if err != nil {
+		slog.Errorf("Error loading modules of guild %v: %v", guildID, err)
+		return
 	}
 
-	for _, module := range botsdef.Modules {
-		botInstance := botsdef.CreateBotInstance(session, module)
-		if botInstance != nil {
-			gm.Bots[id][module] = botInstance
-			botInstance.Start(id)
+	for _, p := range gm.plugins {
+		if enabled[p.Name] {
+			gm.startModule(guildID, p)
+		} else {
+			gm.stopModule(guildID, p.Name)
 		}
 	}
 }
 
-// removeBotInstance removes a bot instance from the GuildManager's Bots map for the given guildID.
+// startModule starts serving the given guild with a module, unless the guild is not registered.
 //
 // Parameters:
-// - guildID string: the ID of the guild from which the bot instance will be removed.
-// No return type.
-func (gm *GuildManager) removeBotInstance(guildID string) {
-	bots, ok := gm.Bots[guildID]
-	if !ok {
+// - guildID: string
+// - p: the module
+func (gm *GuildManager) startModule(guildID string, p botsdef.Plugin) {
+	botInstance := gm.Modules[p.Name]
+	if !gm.Guilds.AddModule(guildID, p.Name, botInstance) {
 		return
 	}
 
-	// Iterate through modules and remove each bot
-	for _, module := range botsdef.Modules {
-		if bot, ok := bots[module]; ok {
-			bot.Stop()
-			delete(bots, module)
-		}
+	if botInstance.State(guildID) == lifecycle.Running {
+		return
+	}
+	if err := botInstance.Start(gm.ctx, guildID); err != nil {
+		slog.Errorf("Error starting module %v for guild %v: %v", p.Name, guildID, err)
 	}
-
-	delete(gm.Bots, guildID)
 }
 
-// parseCommand parses the input based on the provided pattern
+// stopModule stops serving the given guild with a module, if it serves it.
 //
-// input: the input string to be parsed
-// pattern: the pattern to match at the beginning of the input
-// string: the parsed command
-// string: the parsed parameter
-// error: an error if the pattern is not found or no command is found
-func parseCommand(input, pattern string) (string, string, error) {
-	input = strings.ToLower(input)
-	pattern = strings.ToLower(pattern)
-
-	if !strings.HasPrefix(input, pattern) {
-		return "", "", nil // fmt.Errorf("pattern not found")
-	}
-
-	input = input[len(pattern):]
-
-	words := strings.Fields(input)
-	if len(words) == 0 {
-		return "", "", fmt.Errorf("no command found")
+// Parameters:
+// - guildID: string
+// - module: the name of the module
+func (gm *GuildManager) stopModule(guildID, module string) {
+	if bot, ok := gm.Guilds.Module(guildID, module); ok {
+		bot.Stop(guildID)
 	}
+}
 
-	command := words[0]
-	parameter := ""
-	if len(words) > 1 {
-		parameter = strings.Join(words[1:], " ")
-		parameter = strings.TrimSpace(parameter)
+// removeBotInstance unregisters the given guild and stops serving it with every module.
+// The guild state of the modules is kept, so registering the guild again restarts them. The caller holds gm.mu.
+//
+// Parameters:
+// - guildID string: the ID of the guild whose bot instances will be stopped.
+// No return type.
+func (gm *GuildManager) removeBotInstance(guildID string) {
+	for _, bot := range gm.Guilds.Unregister(guildID) {
+		bot.Stop(guildID)
 	}
-	return command, parameter, nil
 }
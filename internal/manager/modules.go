@@ -0,0 +1,128 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/botsdef"
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/internal/lifecycle"
+	"github.com/keshon/dice-roller/internal/router"
+	"github.com/keshon/dice-roller/internal/version"
+)
+
+// handleModuleCommand lists the modules of the guild, or enables or disables one of them,
+// e.g. "module disable generator".
+func (gm *GuildManager) handleModuleCommand(ctx *router.Context) {
+	fields := strings.Fields(ctx.Param)
+	if len(fields) == 0 {
+		gm.listModules(ctx)
+		return
+	}
+
+	if len(fields) != 2 || (fields[0] != "enable" && fields[0] != "disable") {
+		ctx.Reply(fmt.Sprintf("Error: use `%smodule enable <module>` or `%smodule disable <module>`", ctx.Prefix, ctx.Prefix))
+		return
+	}
+
+	p, ok := botsdef.Lookup(fields[1])
+	if !ok {
+		ctx.Reply(fmt.Sprintf("Error: unknown module `%s`, see `%smodules`", fields[1], ctx.Prefix))
+		return
+	}
+
+	guildID := ctx.Message.GuildID
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	enabled, err := botsdef.EnabledModules(gm.plugins, guildID)
+	if err != nil {
+		slog.Errorf("Error loading modules of guild %v: %v", guildID, err)
+		ctx.Reply("Error loading modules")
+		return
+	}
+
+	enable := fields[0] == "enable"
+	if err := checkDependencies(gm.plugins, enabled, p, enable); err != nil {
+		ctx.Reply("Error: " + err.Error())
+		return
+	}
+
+	if err := db.SaveGuildModule(db.GuildModule{GuildID: guildID, Module: p.Name, Enabled: enable}); err != nil {
+		slog.Errorf("Error saving module %v of guild %v: %v", p.Name, guildID, err)
+		ctx.Reply("Error saving module")
+		return
+	}
+
+	if gm.Guilds.Registered(guildID) {
+		gm.setupBotInstance(guildID)
+	}
+
+	if enable {
+		ctx.Reply(fmt.Sprintf("Module `%s` enabled", p.Name))
+	} else {
+		ctx.Reply(fmt.Sprintf("Module `%s` disabled", p.Name))
+	}
+}
+
+// listModules sends the modules with their version and whether they are enabled in the guild.
+func (gm *GuildManager) listModules(ctx *router.Context) {
+	guildID := ctx.Message.GuildID
+	enabled, err := botsdef.EnabledModules(gm.plugins, guildID)
+	if err != nil {
+		slog.Errorf("Error loading modules of guild %v: %v", guildID, err)
+		ctx.Reply("Error loading modules")
+		return
+	}
+
+	var lines []string
+	for _, p := range gm.plugins {
+		state := "disabled"
+		if enabled[p.Name] {
+			state = "enabled"
+			if bot, ok := gm.Guilds.Module(guildID, p.Name); ok && bot.State(guildID) == lifecycle.Error {
+				state = "enabled, failed to start"
+			}
+		}
+		line := fmt.Sprintf("`%s` v%s - %s", p.Name, p.Version, state)
+		if len(p.Dependencies) > 0 {
+			line += " (needs `" + strings.Join(p.Dependencies, "`, `") + "`)"
+		}
+		lines = append(lines, line)
+	}
+
+	ctx.Session.ChannelMessageSendEmbed(ctx.Message.ChannelID, &discordgo.MessageEmbed{
+		Title:       "🧩 Modules",
+		Description: strings.Join(lines, "\n"),
+		Color:       ctx.Settings.EmbedColor,
+		Footer:      &discordgo.MessageEmbedFooter{Text: version.AppFullName},
+	})
+}
+
+// checkDependencies checks that a module can be enabled or disabled: enabling needs its dependencies
+// to be enabled, and disabling is refused while an enabled module depends on it.
+func checkDependencies(plugins []botsdef.Plugin, enabled map[string]bool, p botsdef.Plugin, enable bool) error {
+	if enable {
+		for _, dep := range p.Dependencies {
+			if !enabled[dep] {
+				return fmt.Errorf("module `%s` needs `%s`, enable it first", p.Name, dep)
+			}
+		}
+		return nil
+	}
+
+	for _, other := range plugins {
+		if !enabled[other.Name] {
+			continue
+		}
+		for _, dep := range other.Dependencies {
+			if dep == p.Name {
+				return fmt.Errorf("module `%s` needs `%s`, disable it first", other.Name, p.Name)
+			}
+		}
+	}
+	return nil
+}
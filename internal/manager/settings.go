@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/botsdef"
+	"github.com/keshon/dice-roller/internal/router"
+	"github.com/keshon/dice-roller/internal/settings"
+	"github.com/keshon/dice-roller/internal/version"
+)
+
+// settingNames are the names of the settings shown to users.
+var settingNames = map[string]string{
+	"prefix": "Prefix",
+	"locale": "Locale",
+	"color":  "Embed color",
+	"roll":   "Default roll",
+	"roles":  "Admin roles",
+
+	"user-limit":    "User rate limit",
+	"channel-limit": "Channel rate limit",
+	"guild-limit":   "Server rate limit",
+}
+
+// localeNote tells that the locale is only stored, the replies are in English until they are translated.
+const localeNote = "stored for future translations, replies are in English"
+
+// handleSettingsCommand shows the settings of the guild, or changes one of them, e.g. "settings prefix !".
+func (gm *GuildManager) handleSettingsCommand(ctx *router.Context) {
+	if gm.Router.Settings == nil {
+		ctx.Reply("Error: settings are not available")
+		return
+	}
+
+	fields := strings.Fields(ctx.Raw)
+	if len(fields) == 0 {
+		gm.showSettings(ctx)
+		return
+	}
+
+	if len(fields) < 2 {
+		ctx.Reply(fmt.Sprintf("Error: use `%ssettings <setting> <value>`, settings are %s", ctx.Prefix, strings.Join(settings.Keys, ", ")))
+		return
+	}
+
+	key := strings.ToLower(fields[0])
+	value := strings.Join(fields[1:], " ")
+	if key == "roles" && !ctx.HasPermission(discordgo.PermissionManageServer) {
+		// the admin roles can't hand out or take away the admin roles
+		ctx.Reply("Error: you need the Manage Server permission to change the admin roles")
+		return
+	}
+	guildSettings, err := gm.Router.Settings.Set(ctx.Message.GuildID, key, value)
+	if err != nil {
+		ctx.Reply(fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	reply := fmt.Sprintf("%s set to %s", settingNames[key], guildSettings.Value(key))
+	switch key {
+	case "prefix":
+		reply += fmt.Sprintf(", e.g. `%shelp`", guildSettings.Prefix)
+	case "locale":
+		reply += ", " + localeNote
+	}
+	ctx.Reply(reply)
+}
+
+// showSettings sends the settings of the guild and its enabled modules.
+func (gm *GuildManager) showSettings(ctx *router.Context) {
+	guildID := ctx.Message.GuildID
+	guildSettings := gm.Router.Settings.Get(guildID)
+
+	fields := make([]*discordgo.MessageEmbedField, 0, len(settings.Keys)+1)
+	for _, key := range settings.Keys {
+		value := guildSettings.Value(key)
+		if key == "locale" {
+			value += " (" + localeNote + ")"
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s (`%s`)", settingNames[key], key),
+			Value:  value,
+			Inline: true,
+		})
+	}
+
+	enabled, err := botsdef.EnabledModules(gm.plugins, guildID)
+	if err != nil {
+		slog.Errorf("Error loading modules of guild %v: %v", guildID, err)
+	} else {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Enabled modules", Value: enabledList(enabled)})
+	}
+
+	ctx.Session.ChannelMessageSendEmbed(ctx.Message.ChannelID, &discordgo.MessageEmbed{
+		Title:       "⚙️ Settings",
+		Description: fmt.Sprintf("Change one with `%ssettings <setting> <value>`, or `reset` it to the default.", ctx.Prefix),
+		Fields:      fields,
+		Color:       guildSettings.EmbedColor,
+		Footer:      &discordgo.MessageEmbedFooter{Text: version.AppFullName},
+	})
+}
+
+// enabledList returns the names of the enabled modules, sorted.
+func enabledList(enabled map[string]bool) string {
+	var names []string
+	for name, on := range enabled {
+		if on {
+			names = append(names, "`"+name+"`")
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,245 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often the buckets refilled since they were last used are dropped.
+const pruneInterval = time.Minute
+
+// Scope is what a bucket limits: a user, a channel or a whole guild.
+type Scope string
+
+const (
+	ScopeUser    Scope = "user"
+	ScopeChannel Scope = "channel"
+	ScopeGuild   Scope = "guild"
+)
+
+// Limit allows Count requests per Per on average, in bursts of up to Count requests.
+// The zero Limit allows every request.
+type Limit struct {
+	Count int
+	Per   time.Duration
+}
+
+// Off reports whether the limit allows every request.
+func (l Limit) Off() bool {
+	return l.Count <= 0 || l.Per <= 0
+}
+
+// String returns the limit as ParseLimit reads it, e.g. "5/10s" or "off".
+func (l Limit) String() string {
+	if l.Off() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Count, formatPeriod(l.Per))
+}
+
+// formatPeriod formats a period the short way, e.g. "10s", "1m" or "1h", instead of "1m0s" or "1h0m0s".
+func formatPeriod(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.String()
+}
+
+// ParseLimit reads a limit such as "5/10s", 5 requests every 10 seconds, "30/1m" or "off".
+func ParseLimit(value string) (Limit, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "off" {
+		return Limit{}, nil
+	}
+
+	count, per, ok := strings.Cut(value, "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("limit must look like `5/10s`, 5 commands every 10 seconds, or be `off`")
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d < time.Second {
+		return Limit{}, fmt.Errorf("limit period must be a duration of a second or more, like `10s` or `1m`")
+	}
+	return Limit{Count: n, Per: d}, nil
+}
+
+// Limits are the limits of a guild for each scope.
+type Limits struct {
+	User    Limit
+	Channel Limit
+	Guild   Limit
+}
+
+// DefaultLimits keep a busy channel under the 5 messages per 5 seconds Discord lets a bot send there.
+var DefaultLimits = Limits{
+	User:    Limit{Count: 5, Per: 10 * time.Second},
+	Channel: Limit{Count: 5, Per: 5 * time.Second},
+	Guild:   Limit{Count: 20, Per: 10 * time.Second},
+}
+
+// Decision is the outcome of a request.
+type Decision struct {
+	Allowed bool
+	// Scope is the scope of the bucket that throttled the request.
+	Scope Scope
+	// RetryAfter is how long until the request would be allowed.
+	RetryAfter time.Duration
+	// Notify is true for the first throttled request of a bucket, so the cooldown is announced once.
+	Notify bool
+}
+
+// Metrics count the requests the limiter saw.
+type Metrics struct {
+	Allowed   uint64
+	Throttled uint64
+	// ThrottledBy counts the throttled requests by the scope that throttled them.
+	ThrottledBy map[Scope]uint64
+	// ThrottledGuilds counts the throttled requests by guild, direct messages under "".
+	ThrottledGuilds map[string]uint64
+	// Buckets is the number of buckets in use.
+	Buckets int
+}
+
+type key struct {
+	scope   Scope
+	guildID string
+	id      string
+}
+
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+	warned bool
+}
+
+// refill adds the tokens earned since the bucket was last used, up to the limit.
+func (b *bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * float64(b.limit.Count) / b.limit.Per.Seconds()
+	if b.tokens > float64(b.limit.Count) {
+		b.tokens = float64(b.limit.Count)
+	}
+	b.last = now
+}
+
+// wait returns how long until the bucket has a token.
+func (b *bucket) wait() time.Duration {
+	return time.Duration((1 - b.tokens) * float64(b.limit.Per) / float64(b.limit.Count))
+}
+
+// Limiter is a token bucket rate limiter of the requests of users, channels and guilds.
+// It is safe for concurrent use.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[key]*bucket
+	metrics   Metrics
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// New creates a limiter.
+func New() *Limiter {
+	return &Limiter{
+		buckets: make(map[key]*bucket),
+		metrics: Metrics{ThrottledBy: make(map[Scope]uint64), ThrottledGuilds: make(map[string]uint64)},
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the buckets of the user, the channel and the guild, and allows the request when
+// each of them has one. A throttled request takes no token, so spamming doesn't delay the others further.
+// Direct messages, with an empty guild ID, are limited by user only.
+func (l *Limiter) Allow(guildID, channelID, userID string, limits Limits) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastPrune) >= pruneInterval {
+		l.prune(now)
+	}
+
+	keys := []key{{ScopeUser, guildID, userID}}
+	limitsOf := []Limit{limits.User}
+	if guildID != "" {
+		keys = append(keys, key{ScopeChannel, guildID, channelID}, key{ScopeGuild, guildID, guildID})
+		limitsOf = append(limitsOf, limits.Channel, limits.Guild)
+	}
+
+	var buckets []*bucket
+	var throttled *bucket
+	decision := Decision{Allowed: true}
+	for i, k := range keys {
+		if limitsOf[i].Off() {
+			continue
+		}
+		b := l.bucket(k, limitsOf[i], now)
+		buckets = append(buckets, b)
+		if b.tokens < 1 && b.wait() > decision.RetryAfter {
+			throttled = b
+			decision = Decision{Scope: k.scope, RetryAfter: b.wait()}
+		}
+	}
+
+	if throttled != nil {
+		decision.Notify = !throttled.warned
+		throttled.warned = true
+		l.metrics.Throttled++
+		l.metrics.ThrottledBy[decision.Scope]++
+		l.metrics.ThrottledGuilds[guildID]++
+		return decision
+	}
+
+	for _, b := range buckets {
+		b.tokens--
+		b.warned = false
+	}
+	l.metrics.Allowed++
+	return decision
+}
+
+// bucket returns the refilled bucket of the key, created full. A bucket whose limit changed starts over with it.
+func (l *Limiter) bucket(k key, limit Limit, now time.Time) *bucket {
+	b, ok := l.buckets[k]
+	if !ok || b.limit != limit {
+		b = &bucket{limit: limit, tokens: float64(limit.Count), last: now}
+		l.buckets[k] = b
+	}
+	b.refill(now)
+	return b
+}
+
+// prune drops the buckets that are full again, they are created full when needed.
+func (l *Limiter) prune(now time.Time) {
+	for k, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Count) {
+			delete(l.buckets, k)
+		}
+	}
+	l.lastPrune = now
+}
+
+// Metrics returns a copy of the metrics.
+func (l *Limiter) Metrics() Metrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	metrics := l.metrics
+	metrics.ThrottledBy = make(map[Scope]uint64, len(l.metrics.ThrottledBy))
+	for scope, count := range l.metrics.ThrottledBy {
+		metrics.ThrottledBy[scope] = count
+	}
+	metrics.ThrottledGuilds = make(map[string]uint64, len(l.metrics.ThrottledGuilds))
+	for guildID, count := range l.metrics.ThrottledGuilds {
+		metrics.ThrottledGuilds[guildID] = count
+	}
+	metrics.Buckets = len(l.buckets)
+	return metrics
+}
@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clock is a fake time moved by the tests.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newLimiter() (*Limiter, *clock) {
+	c := &clock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := New()
+	l.now = c.now
+	return l, c
+}
+
+func TestParseLimit(t *testing.T) {
+	for _, value := range []string{"5/10s", "30/1m", "1/1h", "3/1m30s", "off"} {
+		limit, err := ParseLimit(value)
+		if assert.NoError(t, err, value) {
+			assert.Equal(t, value, limit.String())
+		}
+	}
+
+	for _, value := range []string{"", "5", "0/10s", "-1/10s", "5/10", "5/100ms", "five/10s"} {
+		_, err := ParseLimit(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	limits := Limits{
+		User:    Limit{Count: 2, Per: 10 * time.Second},
+		Channel: Limit{Count: 3, Per: 10 * time.Second},
+		Guild:   Limit{Count: 4, Per: 10 * time.Second},
+	}
+
+	t.Run("User", func(t *testing.T) {
+		l, c := newLimiter()
+		assert.True(t, l.Allow("guild", "channel", "user", limits).Allowed)
+		assert.True(t, l.Allow("guild", "channel", "user", limits).Allowed)
+
+		decision := l.Allow("guild", "channel", "user", limits)
+		assert.Equal(t, Decision{Scope: ScopeUser, RetryAfter: 5 * time.Second, Notify: true}, decision)
+		decision = l.Allow("guild", "channel", "user", limits)
+		assert.False(t, decision.Allowed)
+		assert.False(t, decision.Notify, "cooldown is announced once")
+
+		c.advance(5 * time.Second)
+		assert.True(t, l.Allow("guild", "channel", "user", limits).Allowed)
+		decision = l.Allow("guild", "channel", "user", limits)
+		assert.False(t, decision.Allowed)
+		assert.True(t, decision.Notify, "a new cooldown is announced again")
+	})
+
+	t.Run("ChannelAndGuild", func(t *testing.T) {
+		l, _ := newLimiter()
+		assert.True(t, l.Allow("guild", "channel", "a", limits).Allowed)
+		assert.True(t, l.Allow("guild", "channel", "b", limits).Allowed)
+		assert.True(t, l.Allow("guild", "channel", "c", limits).Allowed)
+		assert.Equal(t, ScopeChannel, l.Allow("guild", "channel", "d", limits).Scope)
+
+		assert.True(t, l.Allow("guild", "other", "d", limits).Allowed)
+		assert.Equal(t, ScopeGuild, l.Allow("guild", "third", "e", limits).Scope)
+		assert.True(t, l.Allow("other", "channel", "a", limits).Allowed)
+	})
+
+	t.Run("Direct", func(t *testing.T) {
+		l, _ := newLimiter()
+		for i := 0; i < 10; i++ {
+			assert.True(t, l.Allow("", "dm", fmt.Sprint(i), limits).Allowed)
+		}
+		assert.Equal(t, 10, l.Metrics().Buckets)
+	})
+
+	t.Run("Off", func(t *testing.T) {
+		l, _ := newLimiter()
+		for i := 0; i < 10; i++ {
+			assert.True(t, l.Allow("guild", "channel", "user", Limits{}).Allowed)
+		}
+	})
+
+	t.Run("LimitChanged", func(t *testing.T) {
+		l, _ := newLimiter()
+		l.Allow("guild", "channel", "user", limits)
+		l.Allow("guild", "channel", "user", limits)
+		assert.False(t, l.Allow("guild", "channel", "user", limits).Allowed)
+
+		raised := limits
+		raised.User = Limit{Count: 5, Per: 10 * time.Second}
+		assert.True(t, l.Allow("guild", "channel", "user", raised).Allowed)
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		l, c := newLimiter()
+		for i := 0; i < 5; i++ {
+			l.Allow("guild", "channel", "user", limits)
+		}
+		metrics := l.Metrics()
+		assert.Equal(t, uint64(2), metrics.Allowed)
+		assert.Equal(t, uint64(3), metrics.Throttled)
+		assert.Equal(t, map[Scope]uint64{ScopeUser: 3}, metrics.ThrottledBy)
+		assert.Equal(t, map[string]uint64{"guild": 3}, metrics.ThrottledGuilds)
+		assert.Equal(t, 3, metrics.Buckets)
+
+		c.advance(time.Minute)
+		l.Allow("other", "channel", "user", limits)
+		assert.Equal(t, 3, l.Metrics().Buckets, "refilled buckets are pruned")
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		l := New()
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					l.Allow("guild", fmt.Sprint(j%3), fmt.Sprint(i), limits)
+					l.Metrics()
+				}
+			}(i)
+		}
+		wg.Wait()
+		metrics := l.Metrics()
+		assert.Equal(t, uint64(1000), metrics.Allowed+metrics.Throttled)
+	})
+}
@@ -10,19 +10,28 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gookit/slog"
 	"github.com/keshon/dice-roller/internal/botsdef"
+	"github.com/keshon/dice-roller/internal/lifecycle"
+	"github.com/keshon/dice-roller/internal/ratelimit"
+	"github.com/keshon/dice-roller/internal/settings"
 )
 
 type Rest struct {
-	Bots map[string]map[string]botsdef.Discord
+	Guilds   *botsdef.GuildRegistry
+	Settings *settings.Store
+	Limiter  *ratelimit.Limiter
 }
 
-// NewRest initializes a new Rest object with the given botInstances.
+// NewRest initializes a new Rest object with the given guild registry, settings and rate limiter.
 //
-// botInstances: a map of bot instances
+// guilds: the registry of the registered guilds and their modules
+// settings: the settings of the guilds
+// limiter: the rate limiter of the commands
 // Returns a pointer to the newly initialized Rest object
-func NewRest(bots map[string]map[string]botsdef.Discord) *Rest {
+func NewRest(guilds *botsdef.GuildRegistry, settings *settings.Store, limiter *ratelimit.Limiter) *Rest {
 	return &Rest{
-		Bots: bots,
+		Guilds:   guilds,
+		Settings: settings,
+		Limiter:  limiter,
 	}
 }
 
@@ -40,6 +49,7 @@ func (r *Rest) Start(router *gin.Engine) {
 	r.registerLogsRoutes(router.Group("/logs"))
 	r.registerGuildRoutes(router.Group("/guild"))
 	r.registerAvatarRoutes(router.Group("/avatar"))
+	r.registerRateLimitRoutes(router.Group("/ratelimit"))
 }
 
 type GuildInfo struct {
@@ -50,6 +60,18 @@ type GuildSession struct {
 	GuildID     string
 	GuildActive bool
 	BotStatus   string
+	Modules     map[string]lifecycle.State
+}
+
+type GuildSettings struct {
+	GuildID     string
+	Prefix      string
+	Locale      string
+	EmbedColor  string
+	DefaultRoll string
+	AdminRoles  []string
+	Limits      map[ratelimit.Scope]string
+	Modules     map[string]bool
 }
 
 // generateTableOfContents generates a table of contents for the given gin router.
@@ -128,24 +150,88 @@ func (r *Rest) registerLogsRoutes(router *gin.RouterGroup) {
 }
 
 // Examples:
-// http://localhost:8080/guild/info/897053062030585916
-// http://localhost:8080/guild/playing/897053062030585916
+// http://localhost:8080/guild
+// http://localhost:8080/guild/897053062030585916/settings
 
 // registerGuildRoutes registers the guild routes for the Rest struct.
 //
 // It takes a pointer to a gin.RouterGroup as a parameter and has no return type.
 func (r *Rest) registerGuildRoutes(router *gin.RouterGroup) {
 	router.GET("/", func(ctx *gin.Context) {
-		activeSessions := []GuildInfo{}
+		sessions := []GuildSession{}
+
+		for _, guildID := range r.Guilds.Guilds() {
+			sessions = append(sessions, newGuildSession(guildID, r.Guilds.Modules(guildID)))
+		}
+
+		ctx.JSON(http.StatusOK, sessions)
+	})
+
+	router.GET("/:id/settings", func(ctx *gin.Context) {
+		guildID := ctx.Param("id")
+		if !r.Guilds.Registered(guildID) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "guild is not registered"})
+			return
+		}
 
-		for guildID := range r.Bots {
-			activeSessions = append(activeSessions, GuildInfo{GuildID: guildID})
+		plugins, err := botsdef.Plugins()
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		modules, err := botsdef.EnabledModules(plugins, guildID)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 
-		ctx.JSON(http.StatusOK, activeSessions)
+		guildSettings := r.Settings.Get(guildID)
+		ctx.JSON(http.StatusOK, GuildSettings{
+			GuildID:     guildID,
+			Prefix:      guildSettings.Prefix,
+			Locale:      guildSettings.Locale,
+			EmbedColor:  guildSettings.Value("color"),
+			DefaultRoll: guildSettings.DefaultRoll,
+			AdminRoles:  guildSettings.AdminRoles,
+			Limits: map[ratelimit.Scope]string{
+				ratelimit.ScopeUser:    guildSettings.Limits.User.String(),
+				ratelimit.ScopeChannel: guildSettings.Limits.Channel.String(),
+				ratelimit.ScopeGuild:   guildSettings.Limits.Guild.String(),
+			},
+			Modules: modules,
+		})
 	})
 }
 
+// newGuildSession reports the state of each module of a guild. The bot status is "error" when a module failed,
+// "running" when a module runs and "stopped" otherwise.
+//
+// guildID: the ID of the guild
+// bots: the module instances serving the guild
+// Returns the GuildSession of the guild.
+func newGuildSession(guildID string, bots map[string]botsdef.Discord) GuildSession {
+	session := GuildSession{
+		GuildID:   guildID,
+		BotStatus: string(lifecycle.Stopped),
+		Modules:   make(map[string]lifecycle.State, len(bots)),
+	}
+
+	for module, bot := range bots {
+		state := bot.State(guildID)
+		session.Modules[module] = state
+
+		switch {
+		case state == lifecycle.Error:
+			session.BotStatus = string(lifecycle.Error)
+		case state == lifecycle.Running && session.BotStatus != string(lifecycle.Error):
+			session.BotStatus = string(lifecycle.Running)
+		}
+		session.GuildActive = session.GuildActive || state == lifecycle.Running
+	}
+
+	return session
+}
+
 // Examples:
 // http://localhost:8080/avatar
 // http://localhost:8080/avatar/random
@@ -176,3 +262,20 @@ func (r *Rest) registerAvatarRoutes(router *gin.RouterGroup) {
 		ctx.File(imagePath)
 	})
 }
+
+// Examples:
+// http://localhost:8080/ratelimit
+
+// registerRateLimitRoutes registers the routes reporting the throttled commands.
+//
+// router: The gin router group to register the rate limit routes.
+// None.
+func (r *Rest) registerRateLimitRoutes(router *gin.RouterGroup) {
+	router.GET("/", func(ctx *gin.Context) {
+		if r.Limiter == nil {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "rate limiting is not enabled"})
+			return
+		}
+		ctx.JSON(http.StatusOK, r.Limiter.Metrics())
+	})
+}
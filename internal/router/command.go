@@ -0,0 +1,135 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Categories group the commands in the help, in the order they are shown.
+const (
+	CategoryRolls          = "Rolls"
+	CategoryGameSystems    = "Game systems"
+	CategoryGenerators     = "Generators"
+	CategoryContests       = "Contests"
+	CategorySoloPlay       = "Solo play"
+	CategoryGeneral        = "General"
+	CategoryAdministration = "Administration"
+)
+
+// Categories are the help categories in order.
+var Categories = []string{
+	CategoryRolls, CategoryGameSystems, CategoryGenerators, CategoryContests,
+	CategorySoloPlay, CategoryGeneral, CategoryAdministration,
+}
+
+// Command is a command a module declares.
+type Command struct {
+	Name    string
+	Aliases []string
+	Args    []Arg
+	// Category, Description and Examples are shown in the help. Examples are written without the prefix.
+	Category    string
+	Description string
+	Examples    []string
+	// Permission is the Discord permission needed to run the command in a guild, 0 when anyone can.
+	Permission int64
+	// Restricted returns what the lowercased parameter uses that needs Permission, e.g. "table upload"
+	// while anyone rolls on the tables, or "" when it needs none. Nil when the whole command needs Permission.
+	Restricted func(param string) string
+	// GuildOnly refuses the command in direct messages.
+	GuildOnly bool
+	Handler   HandlerFunc
+}
+
+// Arg is an argument of a command.
+type Arg struct {
+	Name     string
+	Optional bool
+	// Variadic takes the rest of the words.
+	Variadic bool
+}
+
+// String returns the argument as shown in the usage, e.g. "<dice>", "[count]" or "<members...>".
+func (a Arg) String() string {
+	name := a.Name
+	if a.Variadic {
+		name += "..."
+	}
+	if a.Optional {
+		return "[" + name + "]"
+	}
+	return "<" + name + ">"
+}
+
+// Matches reports whether the command is called name or has it as an alias.
+func (c *Command) Matches(name string) bool {
+	if c.Name == name {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage returns how to call the command, e.g. "dice teams <count> <members...>".
+func (c *Command) Usage(prefix string) string {
+	parts := []string{prefix + c.Name}
+	for _, arg := range c.Args {
+		parts = append(parts, arg.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// CheckArgs reports an error when the parameter misses required arguments.
+func (c *Command) CheckArgs(raw, prefix string) error {
+	required := 0
+	for _, arg := range c.Args {
+		if !arg.Optional {
+			required++
+		}
+	}
+
+	if len(strings.Fields(raw)) < required {
+		return fmt.Errorf("missing arguments, use `%s`", c.Usage(prefix))
+	}
+	return nil
+}
+
+// check reports an error when the command can't run in the context.
+func (c *Command) check(ctx *Context) error {
+	if ctx.Message.GuildID == "" {
+		if c.GuildOnly {
+			return fmt.Errorf("`%s` is not available in direct messages", c.Name)
+		}
+	} else if c.Permission != 0 {
+		used := c.Name
+		if c.Restricted != nil {
+			used = c.Restricted(ctx.Param)
+		}
+		if used != "" && !ctx.Allowed(c.Permission) {
+			return fmt.Errorf("you need %s to use `%s`", ctx.requirement(c.Permission), used)
+		}
+	}
+
+	return c.CheckArgs(ctx.Raw, ctx.Prefix)
+}
+
+// Subcommands returns a Restricted func for the subcommands of the command, the first word of the parameter,
+// e.g. Subcommands("table", "upload", "remove") restricts "table upload encounters" but not "table encounters".
+func Subcommands(name string, subcommands ...string) func(param string) string {
+	return func(param string) string {
+		fields := strings.Fields(param)
+		if len(fields) == 0 {
+			return ""
+		}
+		for _, subcommand := range subcommands {
+			if fields[0] == subcommand {
+				return name + " " + subcommand
+			}
+		}
+		return ""
+	}
+}
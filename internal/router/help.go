@@ -0,0 +1,151 @@
+package router
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxFieldLength is the length of an embed field value Discord accepts.
+	maxFieldLength = 1024
+	// maxPageLength keeps a help page well under the 6000 characters of an embed, leaving room for its title and footer.
+	maxPageLength = 4000
+	// maxPageFields is the number of fields an embed can have.
+	maxPageFields = 25
+)
+
+// HelpField is a titled block of help text, shown as an embed field.
+type HelpField struct {
+	Name  string
+	Value string
+}
+
+// Summary returns the help line of the command, e.g. "`dice roll` (`r`) - Roll dice".
+func (c *Command) Summary(prefix string) string {
+	line := fmt.Sprintf("`%s%s`", prefix, c.Name)
+	if len(c.Aliases) > 0 {
+		line += " (`" + strings.Join(c.Aliases, "`, `") + "`)"
+	}
+	if c.Description != "" {
+		line += " - " + c.Description
+	}
+	return line
+}
+
+// Help returns the detailed help of the command: its usage, aliases and examples.
+func (c *Command) Help(prefix string) []HelpField {
+	fields := []HelpField{{Name: "Usage", Value: "`" + c.Usage(prefix) + "`"}}
+	if len(c.Aliases) > 0 {
+		fields = append(fields, HelpField{Name: "Aliases", Value: "`" + strings.Join(c.Aliases, "`, `") + "`"})
+	}
+	if len(c.Examples) > 0 {
+		examples := make([]string, len(c.Examples))
+		for i, example := range c.Examples {
+			examples[i] = "`" + prefix + example + "`"
+		}
+		fields = append(fields, splitField("Examples", examples)...)
+	}
+	return fields
+}
+
+// Overview returns the help lines of the commands grouped by category, split into pages that fit in an embed.
+func Overview(commands []*Command, prefix string) [][]HelpField {
+	lines := make(map[string][]string)
+	for _, cmd := range commands {
+		category := cmd.Category
+		if category == "" {
+			category = "Other"
+		}
+		lines[category] = append(lines[category], cmd.Summary(prefix))
+	}
+
+	var fields []HelpField
+	for _, category := range categoryOrder(lines) {
+		fields = append(fields, splitField(category, lines[category])...)
+	}
+	return paginate(fields)
+}
+
+// categoryOrder returns the categories in the order of Categories, the other ones sorted after them.
+func categoryOrder(lines map[string][]string) []string {
+	var order, others []string
+	for _, category := range Categories {
+		if _, ok := lines[category]; ok {
+			order = append(order, category)
+		}
+	}
+	for category := range lines {
+		if !contains(Categories, category) {
+			others = append(others, category)
+		}
+	}
+	sort.Strings(others)
+	return append(order, others...)
+}
+
+// splitField puts the lines into as many fields as needed to stay under the field length.
+func splitField(name string, lines []string) []HelpField {
+	var fields []HelpField
+	var value strings.Builder
+	title := name
+	for _, line := range lines {
+		if len(line) > maxFieldLength {
+			line = cut(line, maxFieldLength-len("…")) + "…"
+		}
+		if value.Len()+len(line)+1 > maxFieldLength {
+			fields = append(fields, HelpField{Name: title, Value: value.String()})
+			title = name + " (continued)"
+			value.Reset()
+		}
+		if value.Len() > 0 {
+			value.WriteString("\n")
+		}
+		value.WriteString(line)
+	}
+	if value.Len() > 0 {
+		fields = append(fields, HelpField{Name: title, Value: value.String()})
+	}
+	return fields
+}
+
+// cut returns the longest start of the text of up to n bytes that doesn't split a character.
+func cut(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
+
+// paginate groups the fields into pages that stay under the page length and field count.
+func paginate(fields []HelpField) [][]HelpField {
+	var pages [][]HelpField
+	var page []HelpField
+	length := 0
+	for _, field := range fields {
+		size := len(field.Name) + len(field.Value)
+		if len(page) > 0 && (length+size > maxPageLength || len(page) == maxPageFields) {
+			pages = append(pages, page)
+			page, length = nil, 0
+		}
+		page = append(page, field)
+		length += size
+	}
+	if len(page) > 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHelp(t *testing.T) {
+	roll := &Command{
+		Name:        "roll",
+		Aliases:     []string{"r"},
+		Args:        []Arg{{Name: "dice", Optional: true, Variadic: true}},
+		Category:    CategoryRolls,
+		Description: "Roll dice",
+		Examples:    []string{"roll 2d20", "roll 4d6kh3"},
+	}
+
+	t.Run("Summary", func(t *testing.T) {
+		assert.Equal(t, "`dice roll` (`r`) - Roll dice", roll.Summary("dice "))
+		assert.Equal(t, "`dice about`", (&Command{Name: "about"}).Summary("dice "))
+	})
+
+	t.Run("Detail", func(t *testing.T) {
+		assert.Equal(t, []HelpField{
+			{Name: "Usage", Value: "`dice roll [dice...]`"},
+			{Name: "Aliases", Value: "`r`"},
+			{Name: "Examples", Value: "`dice roll 2d20`\n`dice roll 4d6kh3`"},
+		}, roll.Help("dice "))
+	})
+
+	t.Run("OverviewCategories", func(t *testing.T) {
+		commands := []*Command{
+			{Name: "register", Category: CategoryAdministration},
+			{Name: "misc", Category: "Extras"},
+			roll,
+			{Name: "help", Category: CategoryGeneral},
+			{Name: "stray"},
+		}
+
+		pages := Overview(commands, "dice ")
+		if assert.Len(t, pages, 1) {
+			var names []string
+			for _, field := range pages[0] {
+				names = append(names, field.Name)
+			}
+			assert.Equal(t, []string{CategoryRolls, CategoryGeneral, CategoryAdministration, "Extras", "Other"}, names)
+		}
+	})
+
+	t.Run("OverviewPages", func(t *testing.T) {
+		var commands []*Command
+		for i := 0; i < 100; i++ {
+			commands = append(commands, &Command{
+				Name:        fmt.Sprintf("cmd%d", i),
+				Category:    CategoryRolls,
+				Description: strings.Repeat("x", 80),
+			})
+		}
+
+		pages := Overview(commands, "dice ")
+		assert.Greater(t, len(pages), 1)
+
+		count := 0
+		for _, page := range pages {
+			length := 0
+			for _, field := range page {
+				assert.LessOrEqual(t, len(field.Value), maxFieldLength)
+				length += len(field.Name) + len(field.Value)
+				count += strings.Count(field.Value, "\n") + 1
+			}
+			assert.LessOrEqual(t, length, maxPageLength)
+			assert.LessOrEqual(t, len(page), maxPageFields)
+		}
+		assert.Equal(t, 100, count, "every command is listed once")
+		assert.Equal(t, CategoryRolls+" (continued)", pages[0][1].Name)
+		assert.Equal(t, CategoryRolls+" (continued)", pages[0][2].Name)
+	})
+
+	t.Run("LongLine", func(t *testing.T) {
+		fields := splitField(CategoryRolls, []string{strings.Repeat("é", maxFieldLength)})
+		if assert.Len(t, fields, 1) {
+			assert.True(t, utf8.ValidString(fields[0].Value), "characters are not split")
+			assert.True(t, strings.HasSuffix(fields[0].Value, "é…"))
+			assert.LessOrEqual(t, len(fields[0].Value), maxFieldLength)
+		}
+	})
+}
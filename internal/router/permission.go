@@ -0,0 +1,95 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// memberPermissions returns the permissions of the author of the message in its channel. Tests replace it.
+var memberPermissions = func(s *discordgo.Session, m *discordgo.MessageCreate) (int64, error) {
+	perms, err := s.State.UserChannelPermissions(m.Author.ID, m.ChannelID)
+	if err != nil {
+		return s.UserChannelPermissions(m.Author.ID, m.ChannelID)
+	}
+	return perms, nil
+}
+
+// permissionNames are the names of the permissions commands usually need.
+var permissionNames = map[int64]string{
+	discordgo.PermissionAdministrator:  "Administrator",
+	discordgo.PermissionManageServer:   "Manage Server",
+	discordgo.PermissionManageChannels: "Manage Channels",
+	discordgo.PermissionManageRoles:    "Manage Roles",
+	discordgo.PermissionManageMessages: "Manage Messages",
+}
+
+// Allowed reports whether the author may use commands needing the permission: they have it in the channel,
+// or they have one of the admin roles of the guild. Everyone is allowed in direct messages.
+func (ctx *Context) Allowed(permission int64) bool {
+	if ctx.Message.GuildID == "" {
+		return true
+	}
+	return ctx.hasAdminRole() || ctx.HasPermission(permission)
+}
+
+// HasPermission reports whether the author has the Discord permission in the channel, ignoring the admin roles.
+func (ctx *Context) HasPermission(permission int64) bool {
+	perms, err := memberPermissions(ctx.Session, ctx.Message)
+	if err != nil {
+		return false
+	}
+	return perms&discordgo.PermissionAdministrator != 0 || perms&permission == permission
+}
+
+// hasAdminRole reports whether the author has one of the admin roles of the guild.
+func (ctx *Context) hasAdminRole() bool {
+	if ctx.Message.Member == nil {
+		return false
+	}
+	for _, role := range ctx.Message.Member.Roles {
+		for _, admin := range ctx.Settings.AdminRoles {
+			if role == admin {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requirement describes what the author needs to use commands needing the permission,
+// e.g. "the Manage Server permission or the GM role". Roles are named, not mentioned, so no one is pinged.
+func (ctx *Context) requirement(permission int64) string {
+	requirement := fmt.Sprintf("the %s permission", permissionName(permission))
+
+	var roles []string
+	for _, id := range ctx.Settings.AdminRoles {
+		roles = append(roles, ctx.roleName(id))
+	}
+	switch len(roles) {
+	case 0:
+		return requirement
+	case 1:
+		return requirement + " or the " + roles[0] + " role"
+	}
+	return requirement + " or one of the " + strings.Join(roles, ", ") + " roles"
+}
+
+// roleName returns the name of a role of the guild, its ID when it is not in the state.
+func (ctx *Context) roleName(id string) string {
+	if ctx.Session != nil && ctx.Session.State != nil {
+		if role, err := ctx.Session.State.Role(ctx.Message.GuildID, id); err == nil {
+			return role.Name
+		}
+	}
+	return id
+}
+
+// permissionName returns the name of a permission as shown in Discord.
+func permissionName(permission int64) string {
+	if name, ok := permissionNames[permission]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", permission)
+}
@@ -0,0 +1,420 @@
+package router
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/channels"
+	"github.com/keshon/dice-roller/internal/ratelimit"
+	"github.com/keshon/dice-roller/internal/settings"
+)
+
+const (
+	// Global is the scope of commands available everywhere.
+	Global = "*"
+	// Direct is the scope of commands available in direct messages.
+	Direct = "@me"
+)
+
+// HandlerFunc handles a command.
+type HandlerFunc func(ctx *Context)
+
+// Handle adapts a handler taking the lowercased parameter of the command.
+func Handle(fn func(s *discordgo.Session, m *discordgo.MessageCreate, param string)) HandlerFunc {
+	return func(ctx *Context) {
+		fn(ctx.Session, ctx.Message, ctx.Param)
+	}
+}
+
+// HandleRaw adapts a handler taking the parameter of the command with its letter case kept.
+func HandleRaw(fn func(s *discordgo.Session, m *discordgo.MessageCreate, raw string)) HandlerFunc {
+	return func(ctx *Context) {
+		fn(ctx.Session, ctx.Message, ctx.Raw)
+	}
+}
+
+// Context is a command being dispatched.
+type Context struct {
+	Session *discordgo.Session
+	Message *discordgo.MessageCreate
+	// Settings are the settings of the guild, the defaults in direct messages.
+	Settings settings.Settings
+	// Prefix is the command prefix of the guild.
+	Prefix  string
+	Command *Command
+	// Name is the name or alias the command was called with.
+	Name string
+	// Param is the lowercased parameter of the command.
+	Param string
+	// Raw is the parameter of the command with its letter case kept.
+	Raw string
+}
+
+// Reply sends a message to the channel of the command.
+func (ctx *Context) Reply(content string) {
+	if _, err := ctx.Session.ChannelMessageSend(ctx.Message.ChannelID, content); err != nil {
+		slog.Errorf("Error replying to %v: %v", ctx.Name, err)
+	}
+}
+
+// Listener sees every message of its scope before commands are dispatched. It returns true when it handled the message.
+type Listener func(s *discordgo.Session, m *discordgo.MessageCreate) bool
+
+// Module is the set of commands a module mounts on the router.
+type Module struct {
+	Name     string
+	Commands []*Command
+	Listener Listener
+	// Serves reports whether the module serves a scope, nil when it serves every scope it is mounted on.
+	// It lets a module mounted once globally serve only the guilds it is started in.
+	Serves func(scope string) bool
+	// Unrestricted modules answer in every channel whatever the channel rules of the guild,
+	// e.g. so the administration commands can change them.
+	Unrestricted bool
+}
+
+// serves reports whether the module serves the scope.
+func (m Module) serves(scope string) bool {
+	return m.Serves == nil || m.Serves(scope)
+}
+
+type mount struct {
+	id     int
+	module Module
+}
+
+// Router parses prefixed messages once and dispatches them to the commands modules mount on it,
+// per guild, in direct messages or globally.
+type Router struct {
+	Session *discordgo.Session
+	prefix  string
+
+	// Unmounted is called instead of the unknown command reply in guilds without any module mounted,
+	// e.g. to ask for the guild to be registered.
+	Unmounted HandlerFunc
+
+	// Settings holds the settings of the guilds, such as their command prefix.
+	// Without it every guild uses the default settings with the prefix the router was created with.
+	Settings *settings.Store
+
+	// Limiter throttles the commands of users, channels and guilds sending too many, with the limits of their guild.
+	// Without it commands are not limited.
+	Limiter *ratelimit.Limiter
+
+	// Channels holds the channel rules of the guilds: the channels the bot, or one of its modules, answers in.
+	// Without it the bot answers in every channel.
+	Channels *channels.Store
+
+	mu     sync.RWMutex
+	nextID int
+	scopes map[string][]mount
+}
+
+// New creates a router for the session and the default command prefix.
+func New(session *discordgo.Session, prefix string) *Router {
+	return &Router{
+		Session: session,
+		prefix:  prefix,
+		scopes:  make(map[string][]mount),
+	}
+}
+
+// GuildSettings returns the settings of a guild, the defaults for an empty guild ID.
+func (r *Router) GuildSettings(guildID string) settings.Settings {
+	if r.Settings == nil {
+		return settings.Defaults(r.prefix)
+	}
+	return r.Settings.Get(guildID)
+}
+
+// Start adds the message handler of the router to the session.
+func (r *Router) Start() {
+	slog.Info("Command router started")
+	r.Session.AddHandler(r.Handle)
+}
+
+// Mount mounts the commands of a module on a scope: a guild ID, Direct or Global.
+// The returned function unmounts them.
+func (r *Router) Mount(scope string, module Module) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	id := r.nextID
+	r.scopes[scope] = append(r.scopes[scope], mount{id: id, module: module})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { r.unmount(scope, id) })
+	}
+}
+
+// unmount removes the mount with the given ID from the scope.
+func (r *Router) unmount(scope string, id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mounts := r.scopes[scope]
+	for i, m := range mounts {
+		if m.id == id {
+			r.scopes[scope] = append(mounts[:i:i], mounts[i+1:]...)
+			break
+		}
+	}
+	if len(r.scopes[scope]) == 0 {
+		delete(r.scopes, scope)
+	}
+}
+
+// Mounted reports whether any module serves the scope, not counting the global modules serving every scope.
+func (r *Router) Mounted(scope string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, m := range r.scopes[scope] {
+		if m.module.serves(scope) {
+			return true
+		}
+	}
+	for _, m := range r.scopes[Global] {
+		if m.module.Serves != nil && m.module.Serves(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// modules returns the modules serving the scope, the ones mounted on it followed by the global ones.
+func (r *Router) modules(scope string) []Module {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	modules := make([]Module, 0, len(r.scopes[scope])+len(r.scopes[Global]))
+	for _, m := range r.scopes[scope] {
+		if m.module.serves(scope) {
+			modules = append(modules, m.module)
+		}
+	}
+	if scope != Global {
+		for _, m := range r.scopes[Global] {
+			if m.module.serves(scope) {
+				modules = append(modules, m.module)
+			}
+		}
+	}
+	return modules
+}
+
+// known reports whether the name is, or is close to, a command of a module mounted anywhere,
+// whether or not the module serves the scope of the message.
+func (r *Router) known(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var names []string
+	for _, mounts := range r.scopes {
+		for _, m := range mounts {
+			for _, cmd := range m.module.Commands {
+				if cmd.Matches(name) {
+					return true
+				}
+				names = append(names, cmd.Name)
+			}
+		}
+	}
+	return Suggest(name, names) != ""
+}
+
+// Commands returns the commands of the scope followed by the global ones.
+func (r *Router) Commands(scope string) []*Command {
+	var commands []*Command
+	for _, module := range r.modules(scope) {
+		for _, cmd := range module.Commands {
+			if lookupIn(commands, cmd.Name) == nil {
+				commands = append(commands, cmd)
+			}
+		}
+	}
+	return commands
+}
+
+// Lookup finds the command called name in the scope or globally.
+func (r *Router) Lookup(scope, name string) *Command {
+	return lookup(r.modules(scope), name)
+}
+
+// lookup finds the command called name in the modules.
+func lookup(modules []Module, name string) *Command {
+	for _, module := range modules {
+		if cmd := lookupIn(module.Commands, name); cmd != nil {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// lookupIn finds the command called name in the commands.
+func lookupIn(commands []*Command, name string) *Command {
+	for _, cmd := range commands {
+		if cmd.Matches(name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// Handle dispatches a message to the listeners and the command of its scope.
+func (r *Router) Handle(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Author == nil || (s.State != nil && s.State.User != nil && m.Author.ID == s.State.User.ID) {
+		return
+	}
+
+	scope := Scope(m.GuildID)
+	modules, restricted := r.channelModules(m, r.modules(scope))
+
+	for _, module := range modules {
+		if module.Listener != nil && module.Listener(s, m) {
+			return
+		}
+	}
+
+	guildSettings := r.GuildSettings(m.GuildID)
+	name, raw, ok := Parse(m.Content, guildSettings.Prefix)
+	if !ok {
+		return
+	}
+
+	ctx := &Context{
+		Session:  s,
+		Message:  m,
+		Settings: guildSettings,
+		Prefix:   guildSettings.Prefix,
+		Name:     name,
+		Param:    strings.ToLower(raw),
+		Raw:      raw,
+	}
+
+	ctx.Command = lookup(modules, name)
+	if ctx.Command == nil && (restricted || !r.known(name)) {
+		// the command may be one the channel rules turn off, the bot keeps quiet in such channels,
+		// and it leaves the commands of other bots sharing its prefix alone
+		return
+	}
+
+	if r.throttled(ctx) {
+		return
+	}
+
+	if ctx.Command == nil {
+		if scope != Direct && !r.Mounted(scope) && r.Unmounted != nil {
+			r.Unmounted(ctx)
+			return
+		}
+		ctx.Reply(unknownCommand(name, ctx.Prefix, modules))
+		return
+	}
+
+	if err := ctx.Command.check(ctx); err != nil {
+		ctx.Reply(fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	slog.Infof("Command %v from %v: %v", ctx.Command.Name, m.Author.ID, raw)
+	ctx.Command.Handler(ctx)
+}
+
+// throttled reports whether the limiter throttles the command, and announces the cooldown to the first one throttled.
+func (r *Router) throttled(ctx *Context) bool {
+	if r.Limiter == nil {
+		return false
+	}
+
+	m := ctx.Message
+	decision := r.Limiter.Allow(m.GuildID, m.ChannelID, m.Author.ID, ctx.Settings.Limits)
+	if decision.Allowed {
+		return false
+	}
+
+	slog.Debugf("Throttled %v from %v in %v by the %v limit", ctx.Name, m.Author.ID, m.ChannelID, decision.Scope)
+	if decision.Notify {
+		ctx.Reply(cooldown(decision))
+	}
+	return true
+}
+
+// channelModules returns the modules answering in the channel of the message,
+// and reports whether the channel rules of the guild left some out.
+func (r *Router) channelModules(m *discordgo.MessageCreate, modules []Module) ([]Module, bool) {
+	if r.Channels == nil || m.GuildID == "" {
+		return modules, false
+	}
+
+	rules := r.Channels.Rules(m.GuildID)
+	if len(rules) == 0 {
+		return modules, false
+	}
+
+	allowed := make([]Module, 0, len(modules))
+	for _, module := range modules {
+		if module.Unrestricted || rules.Allows(m.ChannelID, module.Name) {
+			allowed = append(allowed, module)
+		}
+	}
+	return allowed, len(allowed) < len(modules)
+}
+
+// cooldown returns the reply to the first command a limit throttles, e.g. "Slow down, try again in 3s".
+func cooldown(decision ratelimit.Decision) string {
+	seconds := int(math.Ceil(decision.RetryAfter.Seconds()))
+	switch decision.Scope {
+	case ratelimit.ScopeChannel:
+		return fmt.Sprintf("Slow down, this channel sent too many commands, try again in %ds", seconds)
+	case ratelimit.ScopeGuild:
+		return fmt.Sprintf("Slow down, this server sent too many commands, try again in %ds", seconds)
+	}
+	return fmt.Sprintf("Slow down, try again in %ds", seconds)
+}
+
+// Scope returns the scope of a message or interaction from its guild ID: the guild, or Direct in direct messages.
+func Scope(guildID string) string {
+	if guildID == "" {
+		return Direct
+	}
+	return guildID
+}
+
+// Parse splits a message into the lowercased command name and the rest of it with its letter case kept.
+// It reports false when the message doesn't start with the prefix or has no command.
+func Parse(content, prefix string) (string, string, bool) {
+	if len(content) < len(prefix) || !strings.EqualFold(content[:len(prefix)], prefix) {
+		return "", "", false
+	}
+
+	words := strings.Fields(content[len(prefix):])
+	if len(words) == 0 {
+		return "", "", false
+	}
+
+	return strings.ToLower(words[0]), strings.Join(words[1:], " "), true
+}
+
+// unknownCommand returns the reply to an unknown command, with the closest command name when there is one.
+func unknownCommand(name, prefix string, modules []Module) string {
+	var names []string
+	for _, module := range modules {
+		for _, cmd := range module.Commands {
+			names = append(names, cmd.Name)
+			names = append(names, cmd.Aliases...)
+		}
+	}
+
+	if suggestion := Suggest(name, names); suggestion != "" {
+		return fmt.Sprintf("Unknown command `%s`, did you mean `%s%s`?", name, prefix, suggestion)
+	}
+	return fmt.Sprintf("Unknown command `%s`, see `%shelp`", name, prefix)
+}
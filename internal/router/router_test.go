@@ -0,0 +1,352 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keshon/dice-roller/internal/channels"
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/internal/lifecycle"
+	"github.com/keshon/dice-roller/internal/ratelimit"
+	"github.com/keshon/dice-roller/internal/settings"
+)
+
+func message(guildID, content string) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{Message: &discordgo.Message{
+		GuildID:   guildID,
+		ChannelID: "channel",
+		Content:   content,
+		Author:    &discordgo.User{ID: "user"},
+	}}
+}
+
+func TestParse(t *testing.T) {
+	t.Run("KeepsParameterCase", func(t *testing.T) {
+		name, raw, ok := Parse("Dice ROLL  2d6   Fireball", "dice ")
+		assert.True(t, ok)
+		assert.Equal(t, "roll", name)
+		assert.Equal(t, "2d6 Fireball", raw)
+	})
+
+	t.Run("NoPrefix", func(t *testing.T) {
+		_, _, ok := Parse("roll 2d6", "dice ")
+		assert.False(t, ok)
+	})
+
+	t.Run("NoCommand", func(t *testing.T) {
+		_, _, ok := Parse("dice    ", "dice ")
+		assert.False(t, ok)
+	})
+}
+
+func TestSuggest(t *testing.T) {
+	names := []string{"roll", "r", "deck", "define", "oracle"}
+
+	assert.Equal(t, "roll", Suggest("rol", names))
+	assert.Equal(t, "deck", Suggest("dekc", names))
+	assert.Equal(t, "oracle", Suggest("orcale", names))
+	assert.Equal(t, "", Suggest("banana", names))
+	assert.Equal(t, "", Suggest("x", names), "single letters are not suggested")
+}
+
+func TestCommand(t *testing.T) {
+	cmd := &Command{
+		Name:    "teams",
+		Aliases: []string{"t"},
+		Args:    []Arg{{Name: "count"}, {Name: "members", Variadic: true}, {Name: "seed", Optional: true}},
+	}
+
+	assert.True(t, cmd.Matches("teams"))
+	assert.True(t, cmd.Matches("t"))
+	assert.False(t, cmd.Matches("team"))
+	assert.Equal(t, "dice teams <count> <members...> [seed]", cmd.Usage("dice "))
+
+	assert.NoError(t, cmd.CheckArgs("2 @a @b", "dice "))
+	assert.EqualError(t, cmd.CheckArgs("2", "dice "), "missing arguments, use `dice teams <count> <members...> [seed]`")
+}
+
+func TestMount(t *testing.T) {
+	r := New(&discordgo.Session{}, "dice ")
+	guildRoll := &Command{Name: "roll", Aliases: []string{"r"}}
+	globalRoll := &Command{Name: "roll"}
+	register := &Command{Name: "register"}
+
+	r.Mount(Global, Module{Name: "manager", Commands: []*Command{globalRoll, register}})
+	unmount := r.Mount("guild", Module{Name: "dicer", Commands: []*Command{guildRoll}})
+
+	assert.True(t, r.Mounted("guild"))
+	assert.Same(t, guildRoll, r.Lookup("guild", "r"), "guild commands come first")
+	assert.Same(t, register, r.Lookup("guild", "register"))
+	assert.Same(t, globalRoll, r.Lookup("other", "roll"))
+
+	unmount()
+	unmount()
+	assert.False(t, r.Mounted("guild"))
+	assert.Same(t, globalRoll, r.Lookup("guild", "roll"))
+}
+
+func TestHandle(t *testing.T) {
+	t.Run("Dispatch", func(t *testing.T) {
+		r := New(&discordgo.Session{}, "dice ")
+		var got *Context
+		r.Mount("guild", Module{Name: "dicer", Commands: []*Command{
+			{Name: "roll", Aliases: []string{"r"}, Handler: func(ctx *Context) { got = ctx }},
+		}})
+
+		r.Handle(r.Session, message("guild", "dice R 2d6 Sneak"))
+		if assert.NotNil(t, got) {
+			assert.Equal(t, "roll", got.Command.Name)
+			assert.Equal(t, "r", got.Name)
+			assert.Equal(t, "2d6 sneak", got.Param)
+			assert.Equal(t, "2d6 Sneak", got.Raw)
+		}
+	})
+
+	t.Run("Scopes", func(t *testing.T) {
+		r := New(&discordgo.Session{}, "dice ")
+		calls := 0
+		r.Mount("guild", Module{Name: "dicer", Commands: []*Command{
+			{Name: "roll", Handler: func(ctx *Context) { calls++ }},
+		}})
+		r.Mount("other", Module{Name: "dicer"})
+
+		r.Handle(r.Session, message("guild", "dice roll"))
+		r.Handle(r.Session, message("guild", "roll"))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Listener", func(t *testing.T) {
+		r := New(&discordgo.Session{}, "dice ")
+		calls := 0
+		r.Mount("guild", Module{
+			Name:     "dicer",
+			Commands: []*Command{{Name: "roll", Handler: func(ctx *Context) { calls++ }}},
+			Listener: func(s *discordgo.Session, m *discordgo.MessageCreate) bool { return m.Content == "dice roll 1d4" },
+		})
+
+		r.Handle(r.Session, message("guild", "dice roll 1d4"))
+		assert.Equal(t, 0, calls)
+		r.Handle(r.Session, message("guild", "dice roll 1d6"))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Unmounted", func(t *testing.T) {
+		r := New(&discordgo.Session{}, "dice ")
+		var unmounted string
+		r.Unmounted = func(ctx *Context) { unmounted = ctx.Name }
+		r.Mount(Global, Module{Name: "manager", Commands: []*Command{{Name: "register", Handler: func(ctx *Context) {}}}})
+		r.Mount(Global, Module{
+			Name:     "dicer",
+			Commands: []*Command{{Name: "roll", Handler: func(ctx *Context) {}}},
+			Serves:   func(scope string) bool { return false },
+		})
+
+		r.Handle(r.Session, message("guild", "dice roll"))
+		assert.Equal(t, "roll", unmounted)
+
+		r.Handle(r.Session, message("guild", "dice rol"))
+		assert.Equal(t, "rol", unmounted, "close to a command")
+
+		// commands of other bots sharing the prefix get no reply
+		r.Handle(r.Session, message("guild", "dice play despacito"))
+		assert.Equal(t, "rol", unmounted)
+	})
+
+	t.Run("Serves", func(t *testing.T) {
+		r := New(&discordgo.Session{}, "dice ")
+		guilds := lifecycle.NewGuilds[struct{}](nil)
+		calls := 0
+		r.Mount(Global, Module{
+			Name:     "dicer",
+			Commands: []*Command{{Name: "roll", Handler: func(ctx *Context) { calls++ }}},
+			Serves:   guilds.Serves,
+		})
+		var unmounted int
+		r.Unmounted = func(ctx *Context) { unmounted++ }
+
+		_, err := guilds.Start(context.Background(), "guild")
+		assert.NoError(t, err)
+		assert.True(t, r.Mounted("guild"))
+		assert.False(t, r.Mounted("other"))
+
+		r.Handle(r.Session, message("guild", "dice roll"))
+		r.Handle(r.Session, message("other", "dice roll"))
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 1, unmounted)
+
+		guilds.Stop("guild")
+		r.Handle(r.Session, message("guild", "dice roll"))
+		assert.Equal(t, 1, calls)
+		assert.Nil(t, r.Lookup("guild", "roll"))
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	r := New(&discordgo.Session{}, "dice ")
+	r.Limiter = ratelimit.New()
+	calls := 0
+	r.Mount(Global, Module{Name: "dicer", Commands: []*Command{{Name: "roll", Handler: func(ctx *Context) { calls++ }}}})
+
+	limits := settings.Defaults("dice ").Limits
+	for i := 0; i < limits.User.Count; i++ {
+		r.Handle(r.Session, message("guild", "dice roll"))
+	}
+	assert.Equal(t, limits.User.Count, calls)
+
+	// the cooldown is announced to the first throttled command only
+	assert.True(t, r.Limiter.Allow("guild", "channel", "user", limits).Notify)
+	r.Handle(r.Session, message("guild", "dice roll"))
+	assert.Equal(t, limits.User.Count, calls)
+	assert.Equal(t, uint64(2), r.Limiter.Metrics().Throttled)
+
+	assert.Equal(t, "Slow down, try again in 2s", cooldown(ratelimit.Decision{Scope: ratelimit.ScopeUser, RetryAfter: 1500 * time.Millisecond}))
+	assert.Equal(t, "Slow down, this channel sent too many commands, try again in 1s", cooldown(ratelimit.Decision{Scope: ratelimit.ScopeChannel, RetryAfter: time.Second}))
+}
+
+func TestChannels(t *testing.T) {
+	_, err := db.InitDB("file::memory:")
+	if !assert.NoError(t, err) {
+		return
+	}
+	r := New(&discordgo.Session{}, "dice ")
+	r.Channels = channels.NewStore()
+	calls := map[string]int{}
+	r.Mount(Global, Module{Name: "dicer", Commands: []*Command{{Name: "roll", Handler: func(ctx *Context) { calls["roll"]++ }}}})
+	r.Mount(Global, Module{Name: "generator", Commands: []*Command{{Name: "gen", Handler: func(ctx *Context) { calls["gen"]++ }}}})
+	r.Mount(Global, Module{Name: "manager", Unrestricted: true, Commands: []*Command{{Name: "channel", Handler: func(ctx *Context) { calls["channel"]++ }}}})
+
+	send := func(channelID, content string) {
+		m := message("guild", content)
+		m.ChannelID = channelID
+		r.Handle(r.Session, m)
+	}
+
+	assert.NoError(t, r.Channels.Set("guild", channels.Rule{ChannelID: "dice", Allow: true}))
+	assert.NoError(t, r.Channels.Set("guild", channels.Rule{ChannelID: "dice", Module: "generator"}))
+
+	send("dice", "dice roll")
+	send("dice", "dice gen")
+	send("general", "dice roll")
+	send("general", "dice banana")
+	send("general", "dice channel")
+	assert.Equal(t, map[string]int{"roll": 1, "channel": 1}, calls)
+}
+
+// BenchmarkHandle dispatches a command with modules serving a growing number of guilds.
+// The cost per message stays the same whatever the number of guilds.
+func BenchmarkHandle(b *testing.B) {
+	level := slog.Std().Level
+	slog.SetLogLevel(slog.ErrorLevel)
+	defer slog.SetLogLevel(level)
+
+	for _, count := range []int{1, 100, 10000} {
+		b.Run(fmt.Sprintf("Guilds%d", count), func(b *testing.B) {
+			r := New(&discordgo.Session{}, "dice ")
+			for _, name := range []string{"dicer", "generator", "about"} {
+				guilds := lifecycle.NewGuilds[struct{}](nil)
+				for i := 0; i < count; i++ {
+					if _, err := guilds.Start(context.Background(), fmt.Sprint(i)); err != nil {
+						b.Fatal(err)
+					}
+				}
+				r.Mount(Global, Module{
+					Name:     name,
+					Commands: []*Command{{Name: name + "-cmd", Handler: func(ctx *Context) {}}},
+					Listener: func(s *discordgo.Session, m *discordgo.MessageCreate) bool { return false },
+					Serves:   guilds.Serves,
+				})
+			}
+			m := message("0", "dice dicer-cmd 2d6")
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.Handle(r.Session, m)
+			}
+		})
+	}
+}
+
+func TestPermission(t *testing.T) {
+	perms := int64(0)
+	defer func(fn func(*discordgo.Session, *discordgo.MessageCreate) (int64, error)) { memberPermissions = fn }(memberPermissions)
+	memberPermissions = func(s *discordgo.Session, m *discordgo.MessageCreate) (int64, error) { return perms, nil }
+
+	r := New(&discordgo.Session{}, "dice ")
+	calls := 0
+	r.Mount(Global, Module{Name: "manager", Commands: []*Command{
+		{Name: "unregister", Permission: discordgo.PermissionManageServer, Handler: func(ctx *Context) { calls++ }},
+	}})
+
+	t.Run("Denied", func(t *testing.T) {
+		ctx := &Context{Session: r.Session, Message: message("guild", "dice unregister")}
+		cmd := r.Lookup("guild", "unregister")
+		assert.EqualError(t, cmd.check(ctx), "you need the Manage Server permission to use `unregister`")
+
+		ctx.Settings.AdminRoles = []string{"1", "2"}
+		assert.EqualError(t, cmd.check(ctx), "you need the Manage Server permission or one of the 1, 2 roles to use `unregister`")
+		ctx.Settings.AdminRoles = []string{"1"}
+		assert.EqualError(t, cmd.check(ctx), "you need the Manage Server permission or the 1 role to use `unregister`")
+	})
+
+	t.Run("Permission", func(t *testing.T) {
+		perms = discordgo.PermissionManageServer
+		defer func() { perms = 0 }()
+		r.Handle(r.Session, message("guild", "dice unregister"))
+		assert.Equal(t, 1, calls)
+
+		perms = discordgo.PermissionAdministrator
+		r.Handle(r.Session, message("guild", "dice unregister"))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("AdminRole", func(t *testing.T) {
+		m := message("guild", "dice unregister")
+		m.Member = &discordgo.Member{Roles: []string{"player", "gm"}}
+		ctx := &Context{Session: r.Session, Message: m}
+		assert.False(t, ctx.Allowed(discordgo.PermissionManageServer))
+
+		ctx.Settings.AdminRoles = []string{"gm"}
+		assert.True(t, ctx.Allowed(discordgo.PermissionManageServer))
+		assert.False(t, ctx.HasPermission(discordgo.PermissionManageServer))
+	})
+
+	t.Run("Direct", func(t *testing.T) {
+		ctx := &Context{Session: r.Session, Message: message("", "dice unregister")}
+		assert.True(t, ctx.Allowed(discordgo.PermissionManageServer))
+	})
+
+	t.Run("Restricted", func(t *testing.T) {
+		table := &Command{
+			Name:       "table",
+			Permission: discordgo.PermissionManageServer,
+			Restricted: Subcommands("table", "upload", "remove"),
+		}
+		check := func(guildID, param string) error {
+			return table.check(&Context{Session: r.Session, Message: message(guildID, "dice table "+param), Param: param})
+		}
+
+		assert.NoError(t, check("guild", ""))
+		assert.NoError(t, check("guild", "encounters"))
+		assert.NoError(t, check("guild", "show uploaded"))
+		assert.EqualError(t, check("guild", "upload encounters"), "you need the Manage Server permission to use `table upload`")
+		assert.EqualError(t, check("guild", "remove encounters"), "you need the Manage Server permission to use `table remove`")
+		assert.NoError(t, check("", "upload encounters"), "direct messages change the data of the author only")
+
+		perms = discordgo.PermissionManageServer
+		defer func() { perms = 0 }()
+		assert.NoError(t, check("guild", "upload encounters"))
+	})
+}
+
+func TestUnknownCommand(t *testing.T) {
+	modules := []Module{{Commands: []*Command{{Name: "roll", Aliases: []string{"r"}}, {Name: "deck"}}}}
+
+	assert.Equal(t, "Unknown command `rool`, did you mean `dice roll`?", unknownCommand("rool", "dice ", modules))
+	assert.Equal(t, "Unknown command `banana`, see `dice help`", unknownCommand("banana", "dice ", modules))
+}
@@ -0,0 +1,39 @@
+package router
+
+// maxSuggestDistance is how many edits a name can be from a command to be suggested.
+const maxSuggestDistance = 2
+
+// Suggest returns the name closest to the given one, or "" when none is close enough.
+func Suggest(name string, names []string) string {
+	best, bestDistance := "", maxSuggestDistance+1
+	for _, candidate := range names {
+		distance := levenshtein(name, candidate)
+		if distance < bestDistance && distance < len(candidate) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// levenshtein returns the number of single letter insertions, deletions or substitutions turning a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,344 @@
+package settings
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/internal/ratelimit"
+)
+
+const (
+	// DefaultColor is the color of the embeds the bot sends.
+	DefaultColor = 0x9f00d4
+	// DefaultLocale is the locale of the bot.
+	DefaultLocale = "en"
+	// DefaultRoll is the roll used when no dice are given.
+	DefaultRoll = "1d20"
+
+	maxPrefixLength = 10
+)
+
+// Keys are the names of the settings the settings command changes, in display order.
+var Keys = []string{"prefix", "locale", "color", "roll", "roles", "user-limit", "channel-limit", "guild-limit"}
+
+var (
+	localePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+	rolePattern   = regexp.MustCompile(`^(?:<@&)?(\d+)>?$`)
+)
+
+// Settings are the settings of a guild, with the defaults filled in.
+type Settings struct {
+	Prefix string
+	// Locale is stored for future translations, nothing reads it yet.
+	Locale      string
+	EmbedColor  int
+	DefaultRoll string
+	// AdminRoles are the IDs of the roles whose members may use the commands needing a permission without having it.
+	AdminRoles []string
+	// Limits are the rate limits of the commands of each user, channel and of the whole guild.
+	Limits ratelimit.Limits
+}
+
+// Defaults returns the default settings with the given command prefix.
+func Defaults(prefix string) Settings {
+	return Settings{
+		Prefix:      prefix,
+		Locale:      DefaultLocale,
+		EmbedColor:  DefaultColor,
+		DefaultRoll: DefaultRoll,
+		Limits:      ratelimit.DefaultLimits,
+	}
+}
+
+// Value returns the setting with the given key formatted for display.
+func (s Settings) Value(key string) string {
+	switch key {
+	case "prefix":
+		return "`" + s.Prefix + "`"
+	case "locale":
+		return s.Locale
+	case "color":
+		return fmt.Sprintf("#%06x", s.EmbedColor)
+	case "roll":
+		return "`" + s.DefaultRoll + "`"
+	case "roles":
+		if len(s.AdminRoles) == 0 {
+			return "none"
+		}
+		return "<@&" + strings.Join(s.AdminRoles, ">, <@&") + ">"
+	case "user-limit":
+		return "`" + s.Limits.User.String() + "`"
+	case "channel-limit":
+		return "`" + s.Limits.Channel.String() + "`"
+	case "guild-limit":
+		return "`" + s.Limits.Guild.String() + "`"
+	}
+	return ""
+}
+
+// Store loads the settings of the guilds from the database and caches them. It is safe for concurrent use.
+type Store struct {
+	mu         sync.RWMutex
+	defaults   Settings
+	cache      map[string]Settings
+	validators map[string]func(string) error
+
+	load func(guildID string) (*db.GuildSettings, error)
+	save func(settings db.GuildSettings) error
+}
+
+// NewStore creates a store of the guild settings falling back to the given defaults.
+func NewStore(defaults Settings) *Store {
+	return &Store{
+		defaults:   defaults,
+		cache:      make(map[string]Settings),
+		validators: make(map[string]func(string) error),
+		load:       db.GetGuildSettings,
+		save:       db.SaveGuildSettings,
+	}
+}
+
+// Defaults returns the default settings.
+func (s *Store) Defaults() Settings {
+	return s.defaults
+}
+
+// Validate adds a check of the values of a setting, e.g. the default roll is checked by the module rolling it.
+func (s *Store) Validate(key string, fn func(value string) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validators[key] = fn
+}
+
+// Get returns the settings of the guild. An empty guild ID, for direct messages, gets the defaults,
+// and so do guilds whose settings can't be loaded.
+func (s *Store) Get(guildID string) Settings {
+	if guildID == "" {
+		return s.defaults
+	}
+
+	s.mu.RLock()
+	settings, ok := s.cache[guildID]
+	s.mu.RUnlock()
+	if ok {
+		return settings
+	}
+
+	stored, err := s.load(guildID)
+	if err != nil {
+		slog.Errorf("Error loading settings of guild %v: %v", guildID, err)
+		return s.defaults
+	}
+
+	settings = s.merge(stored)
+	s.mu.Lock()
+	s.cache[guildID] = settings
+	s.mu.Unlock()
+	return settings
+}
+
+// Set changes a setting of the guild and saves it. The value "reset" restores the default.
+func (s *Store) Set(guildID, key, value string) (Settings, error) {
+	stored, err := s.load(guildID)
+	if err != nil {
+		return Settings{}, err
+	}
+	if stored == nil {
+		stored = &db.GuildSettings{GuildID: guildID}
+	}
+
+	if err := s.apply(stored, key, value); err != nil {
+		return Settings{}, err
+	}
+	if err := s.save(*stored); err != nil {
+		return Settings{}, err
+	}
+
+	settings := s.merge(stored)
+	s.mu.Lock()
+	s.cache[guildID] = settings
+	s.mu.Unlock()
+	return settings, nil
+}
+
+// apply checks the value and sets it on the stored settings.
+func (s *Store) apply(stored *db.GuildSettings, key, value string) error {
+	reset := strings.EqualFold(value, "reset")
+
+	s.mu.RLock()
+	validate := s.validators[key]
+	s.mu.RUnlock()
+	if validate != nil && !reset {
+		if err := validate(value); err != nil {
+			return err
+		}
+	}
+
+	switch key {
+	case "prefix":
+		if reset {
+			stored.Prefix = ""
+			return nil
+		}
+		prefix, err := parsePrefix(value)
+		if err != nil {
+			return err
+		}
+		stored.Prefix = prefix
+	case "locale":
+		if reset {
+			stored.Locale = ""
+			return nil
+		}
+		if !localePattern.MatchString(value) {
+			return fmt.Errorf("locale must look like `en` or `pt-BR`")
+		}
+		stored.Locale = value
+	case "color":
+		if reset {
+			stored.EmbedColor = 0
+			return nil
+		}
+		color, err := ParseColor(value)
+		if err != nil {
+			return err
+		}
+		stored.EmbedColor = color
+	case "roll":
+		if reset {
+			stored.DefaultRoll = ""
+			return nil
+		}
+		stored.DefaultRoll = value
+	case "roles":
+		if reset {
+			stored.AdminRoles = ""
+			return nil
+		}
+		roles, err := parseRoles(value)
+		if err != nil {
+			return err
+		}
+		stored.AdminRoles = strings.Join(roles, ",")
+	case "user-limit", "channel-limit", "guild-limit":
+		field := limitField(stored, key)
+		if reset {
+			*field = ""
+			return nil
+		}
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			return err
+		}
+		*field = limit.String()
+	default:
+		return fmt.Errorf("unknown setting `%s`, use one of %s", key, strings.Join(Keys, ", "))
+	}
+	return nil
+}
+
+// merge fills the empty stored settings with the defaults.
+func (s *Store) merge(stored *db.GuildSettings) Settings {
+	settings := s.defaults
+	if stored == nil {
+		return settings
+	}
+	if stored.Prefix != "" {
+		settings.Prefix = stored.Prefix
+	}
+	if stored.Locale != "" {
+		settings.Locale = stored.Locale
+	}
+	if stored.EmbedColor != 0 {
+		settings.EmbedColor = stored.EmbedColor
+	}
+	if stored.DefaultRoll != "" {
+		settings.DefaultRoll = stored.DefaultRoll
+	}
+	if stored.AdminRoles != "" {
+		settings.AdminRoles = strings.Split(stored.AdminRoles, ",")
+	}
+	mergeLimit(&settings.Limits.User, stored.UserLimit)
+	mergeLimit(&settings.Limits.Channel, stored.ChannelLimit)
+	mergeLimit(&settings.Limits.Guild, stored.GuildLimit)
+	return settings
+}
+
+// mergeLimit sets the limit to the stored one, when there is one.
+func mergeLimit(limit *ratelimit.Limit, stored string) {
+	if stored == "" {
+		return
+	}
+	parsed, err := ratelimit.ParseLimit(stored)
+	if err != nil {
+		slog.Errorf("Error parsing stored rate limit %v: %v", stored, err)
+		return
+	}
+	*limit = parsed
+}
+
+// limitField returns the stored rate limit the key changes.
+func limitField(stored *db.GuildSettings, key string) *string {
+	switch key {
+	case "user-limit":
+		return &stored.UserLimit
+	case "channel-limit":
+		return &stored.ChannelLimit
+	}
+	return &stored.GuildLimit
+}
+
+// parsePrefix checks a command prefix. A prefix ending with a letter or a digit, e.g. "dice", is followed by a space.
+func parsePrefix(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.ContainsAny(value, "`\n") {
+		return "", fmt.Errorf("prefix can't be empty or contain backticks")
+	}
+
+	last := []rune(value)[len([]rune(value))-1]
+	if unicode.IsLetter(last) || unicode.IsDigit(last) {
+		value += " "
+	}
+
+	if len([]rune(value)) > maxPrefixLength {
+		return "", fmt.Errorf("prefix can be %d characters long at most", maxPrefixLength)
+	}
+	return value, nil
+}
+
+// parseRoles reads role mentions or IDs separated by spaces or commas.
+func parseRoles(value string) ([]string, error) {
+	var roles []string
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		match := rolePattern.FindStringSubmatch(field)
+		if match == nil {
+			return nil, fmt.Errorf("`%s` is not a role, mention roles like `@GM`", field)
+		}
+		roles = append(roles, match[1])
+	}
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("mention at least one role")
+	}
+	return roles, nil
+}
+
+// ParseColor parses a hex color such as "#ff8800", "ff8800" or "0xff8800".
+func ParseColor(value string) (int, error) {
+	hex := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(value), "#"), "0x")
+	color, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return 0, fmt.Errorf("color must be a hex color like `#9f00d4`")
+	}
+	if color == 0 {
+		// 0 means the default color, pure black is the closest one
+		color = 1
+	}
+	return int(color), nil
+}
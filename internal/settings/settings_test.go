@@ -0,0 +1,136 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/internal/ratelimit"
+)
+
+// newTestStore returns a store keeping its settings in a map instead of the database.
+func newTestStore() (*Store, map[string]db.GuildSettings, *int) {
+	stored := make(map[string]db.GuildSettings)
+	loads := 0
+	s := NewStore(Defaults("dice "))
+	s.load = func(guildID string) (*db.GuildSettings, error) {
+		loads++
+		if settings, ok := stored[guildID]; ok {
+			return &settings, nil
+		}
+		return nil, nil
+	}
+	s.save = func(settings db.GuildSettings) error {
+		stored[settings.GuildID] = settings
+		return nil
+	}
+	return s, stored, &loads
+}
+
+func TestStore(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		s, _, loads := newTestStore()
+		assert.Equal(t, Defaults("dice "), s.Get(""))
+		assert.Equal(t, 0, *loads, "direct messages don't load settings")
+
+		assert.Equal(t, Defaults("dice "), s.Get("guild"))
+		s.Get("guild")
+		assert.Equal(t, 1, *loads, "settings are cached")
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		s, stored, _ := newTestStore()
+		s.Get("guild")
+
+		settings, err := s.Set("guild", "prefix", "!")
+		assert.NoError(t, err)
+		assert.Equal(t, "!", settings.Prefix)
+		assert.Equal(t, "!", s.Get("guild").Prefix)
+
+		settings, err = s.Set("guild", "color", "#FF8800")
+		assert.NoError(t, err)
+		assert.Equal(t, 0xff8800, settings.EmbedColor)
+		assert.Equal(t, "!", settings.Prefix, "other settings are kept")
+		assert.Equal(t, db.GuildSettings{GuildID: "guild", Prefix: "!", EmbedColor: 0xff8800}, stored["guild"])
+
+		settings, err = s.Set("guild", "roles", "<@&123> <@&456>,789")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"123", "456", "789"}, settings.AdminRoles)
+		assert.Equal(t, "<@&123>, <@&456>, <@&789>", settings.Value("roles"))
+		assert.Equal(t, "123,456,789", stored["guild"].AdminRoles)
+
+		settings, err = s.Set("guild", "user-limit", "3/30S")
+		assert.NoError(t, err)
+		assert.Equal(t, ratelimit.Limit{Count: 3, Per: 30 * time.Second}, settings.Limits.User)
+		assert.Equal(t, ratelimit.DefaultLimits.Channel, settings.Limits.Channel)
+		assert.Equal(t, "3/30s", stored["guild"].UserLimit)
+
+		settings, err = s.Set("guild", "channel-limit", "off")
+		assert.NoError(t, err)
+		assert.True(t, settings.Limits.Channel.Off())
+		assert.Equal(t, "`off`", settings.Value("channel-limit"))
+
+		settings, err = s.Set("guild", "prefix", "reset")
+		assert.NoError(t, err)
+		assert.Equal(t, "dice ", settings.Prefix)
+		assert.Equal(t, "dice ", s.Get("other").Prefix, "other guilds are not changed")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		s, _, _ := newTestStore()
+		s.Validate("roll", func(value string) error {
+			if value != "2d6" {
+				return fmt.Errorf("bad roll %s", value)
+			}
+			return nil
+		})
+
+		for _, c := range []struct{ key, value, err string }{
+			{"prefix", "`", "prefix can't be empty or contain backticks"},
+			{"prefix", "verylongprefix", "prefix can be 10 characters long at most"},
+			{"locale", "english", "locale must look like `en` or `pt-BR`"},
+			{"color", "purple", "color must be a hex color like `#9f00d4`"},
+			{"roll", "2d", "bad roll 2d"},
+			{"roles", "@everyone", "`@everyone` is not a role, mention roles like `@GM`"},
+			{"guild-limit", "lots", "limit must look like `5/10s`, 5 commands every 10 seconds, or be `off`"},
+			{"mood", "happy", "unknown setting `mood`, use one of prefix, locale, color, roll, roles, user-limit, channel-limit, guild-limit"},
+		} {
+			_, err := s.Set("guild", c.key, c.value)
+			assert.EqualError(t, err, c.err, c.key+" "+c.value)
+		}
+
+		settings, err := s.Set("guild", "roll", "2d6")
+		assert.NoError(t, err)
+		assert.Equal(t, "2d6", settings.DefaultRoll)
+	})
+
+	t.Run("LoadError", func(t *testing.T) {
+		s, _, _ := newTestStore()
+		s.load = func(guildID string) (*db.GuildSettings, error) { return nil, errors.New("database locked") }
+		assert.Equal(t, Defaults("dice "), s.Get("guild"))
+		_, err := s.Set("guild", "prefix", "!")
+		assert.EqualError(t, err, "database locked")
+	})
+}
+
+func TestParsePrefix(t *testing.T) {
+	for value, want := range map[string]string{"!": "!", "dice": "dice ", "r2": "r2 ", "?!": "?!"} {
+		got, err := parsePrefix(value)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, value)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	for value, want := range map[string]int{"#9f00d4": 0x9f00d4, "9F00D4": 0x9f00d4, "0x00ff00": 0x00ff00, "#000000": 1} {
+		got, err := ParseColor(value)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, value)
+	}
+	_, err := ParseColor("#fff")
+	assert.Error(t, err)
+}
@@ -47,7 +47,7 @@ func (d *Discord) handleAboutCommand(s *discordgo.Session, m *discordgo.MessageC
 		AddField("```Created by Innokentiy Sokolov```", "[Linkedin](https://www.linkedin.com/in/keshon), [GitHub](https://github.com/keshon), [Homepage](https://keshon.ru)").
 		InlineAllFields().
 		SetImage(avatarUrl).
-		SetColor(0x9f00d4).SetFooter(version.AppFullName).MessageEmbed
+		SetColor(d.router.GuildSettings(m.GuildID).EmbedColor).SetFooter(version.AppFullName).MessageEmbed
 
 	s.ChannelMessageSendEmbed(m.Message.ChannelID, embedMsg)
 }
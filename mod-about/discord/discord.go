@@ -1,133 +1,90 @@
 package discord
 
 import (
-	"fmt"
-	"strings"
+	"context"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/gookit/slog"
-	"github.com/keshon/dice-roller/internal/config"
+	"github.com/keshon/dice-roller/internal/lifecycle"
+	"github.com/keshon/dice-roller/internal/router"
 	"github.com/keshon/dice-roller/mod-about/utils"
 )
 
 type Discord struct {
 	Session              *discordgo.Session
-	GuildID              string
-	IsInstanceActive     bool
-	CommandPrefix        string
+	avatarMu             sync.Mutex
 	LastChangeAvatarTime time.Time
 	RateLimitDuration    time.Duration
+	router               *router.Router
+	guilds               *lifecycle.Guilds[struct{}]
 }
 
-// NewDiscord initializes a new Discord object with the given session and guild ID.
+// NewDiscord initializes a new Discord object with the given session and mounts its commands and handlers,
+// shared by every guild it is started in.
 //
 // Parameters:
 // - session: a pointer to a discordgo.Session
-// - guildID: a string representing the guild ID
+// - r: the command router
 // Returns a pointer to a Discord object.
-func NewDiscord(session *discordgo.Session) *Discord {
-	config := loadConfig()
-
-	return &Discord{
+func NewDiscord(session *discordgo.Session, r *router.Router) *Discord {
+	d := &Discord{
 		Session:           session,
-		IsInstanceActive:  true,
-		CommandPrefix:     config.DiscordCommandPrefix,
 		RateLimitDuration: time.Minute * 10,
+		router:            r,
+		guilds:            lifecycle.NewGuilds[struct{}](nil),
 	}
+
+	r.Mount(router.Global, router.Module{Name: "about", Commands: d.commands(), Serves: d.guilds.Serves})
+	session.AddHandler(d.Interactions)
+	return d
 }
 
-func loadConfig() *config.Config {
-	cfg, err := config.NewConfig()
-	if err != nil {
-		slog.Fatal("Error loading config", err)
+func (d *Discord) Start(ctx context.Context, guildID string) error {
+	if _, err := d.guilds.Start(ctx, guildID); err != nil {
+		return err
 	}
-	return cfg
-}
 
-func (d *Discord) Start(guildID string) {
 	slog.Info("Discord instance of mod-about started for guild ID", guildID)
-	d.Session.AddHandler(d.Commands)
-	d.GuildID = guildID
-}
-
-func (d *Discord) Stop() {
-	d.IsInstanceActive = false
+	return nil
 }
 
-func (d *Discord) Commands(s *discordgo.Session, m *discordgo.MessageCreate) {
-	if m.GuildID != d.GuildID || !d.IsInstanceActive {
-		return
-	}
-
-	command, _, err := parseCommand(m.Message.Content, d.CommandPrefix)
-	if err != nil {
-		return
-	}
-
-	switch getCanonicalCommand(command, [][]string{
-		{"help", "h"},
-		{"about", "a"},
-	}) {
-	case "help":
-		d.handleHelpCommand(s, m)
-	case "about":
-		d.handleAboutCommand(s, m)
-	}
+func (d *Discord) Stop(guildID string) {
+	d.guilds.Stop(guildID)
 }
 
-// parseCommand parses the input based on the provided pattern
-//
-// input: the input string to be parsed
-// pattern: the pattern to match at the beginning of the input
-// string: the parsed command
-// string: the parsed parameter
-// error: an error if the pattern is not found or no command is found
-func parseCommand(input, pattern string) (string, string, error) {
-	input = strings.ToLower(input)
-	pattern = strings.ToLower(pattern)
-
-	if !strings.HasPrefix(input, pattern) {
-		return "", "", nil // fmt.Errorf("pattern not found")
-	}
-
-	input = input[len(pattern):]
-
-	words := strings.Fields(input)
-	if len(words) == 0 {
-		return "", "", fmt.Errorf("no command found")
-	}
-
-	command := words[0]
-	parameter := ""
-	if len(words) > 1 {
-		parameter = strings.Join(words[1:], " ")
-		parameter = strings.TrimSpace(parameter)
-	}
-	return command, parameter, nil
+func (d *Discord) State(guildID string) lifecycle.State {
+	return d.guilds.State(guildID)
 }
 
-// getCanonicalCommand finds the canonical command for the given alias.
-//
-// Parameters:
-// - alias: a string representing the alias to be searched for.
-// - commandAliases: a 2D slice containing the list of command aliases.
-// Return type: string
-func getCanonicalCommand(alias string, commandAliases [][]string) string {
-	for _, aliases := range commandAliases {
-		for _, command := range aliases {
-			if command == alias {
-				return aliases[0]
-			}
-		}
+// commands returns the commands of the module.
+func (d *Discord) commands() []*router.Command {
+	return []*router.Command{
+		{
+			Name: "help", Aliases: []string{"h"},
+			Args:        []router.Arg{{Name: "command", Optional: true}},
+			Category:    router.CategoryGeneral,
+			Description: "Show help, or the details and examples of a command",
+			Examples:    []string{"help", "help roll"},
+			Handler:     router.Handle(d.handleHelpCommand),
+		},
+		{
+			Name: "about", Aliases: []string{"a"},
+			Category:    router.CategoryGeneral,
+			Description: "Show version",
+			Handler:     func(ctx *router.Context) { d.handleAboutCommand(ctx.Session, ctx.Message) },
+		},
 	}
-	return ""
 }
 
 // changeAvatar changes the avatar of the Discord user.
 //
 // It takes a session as a parameter and does not return anything.
 func (d *Discord) changeAvatar(s *discordgo.Session) {
+	d.avatarMu.Lock()
+	defer d.avatarMu.Unlock()
+
 	if time.Since(d.LastChangeAvatarTime) < d.RateLimitDuration {
 		return
 	}
@@ -3,22 +3,148 @@ package discord
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	embed "github.com/Clinet/discordgo-embed"
 	"github.com/bwmarrin/discordgo"
 	"github.com/gookit/slog"
 	"github.com/keshon/dice-roller/internal/config"
+	"github.com/keshon/dice-roller/internal/router"
+	"github.com/keshon/dice-roller/internal/settings"
 	"github.com/keshon/dice-roller/internal/version"
 	"github.com/keshon/dice-roller/mod-about/utils"
 )
 
-// handleHelpCommand handles the help command for the Discord bot.
+const helpPageButtonPrefix = "help:page:"
+
+// handleHelpCommand handles the help command for the Discord bot: the overview of the commands,
+// or the details and examples of one command, e.g. "help roll".
 //
-// Takes in a session and a message create, and does not return any value.
-func (d *Discord) handleHelpCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+// Takes in a session, a message create and the name of the command, and does not return any value.
+func (d *Discord) handleHelpCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
 	d.changeAvatar(s)
+	guildSettings := d.router.GuildSettings(m.GuildID)
+
+	if fields := strings.Fields(param); len(fields) > 0 {
+		name := fields[0]
+		cmd := d.router.Lookup(m.GuildID, name)
+		if cmd == nil {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: unknown command `%s`, see `%shelp`", name, guildSettings.Prefix))
+			return
+		}
+		s.ChannelMessageSendEmbed(m.ChannelID, renderCommandHelp(cmd, guildSettings))
+		return
+	}
+
+	pages := router.Overview(d.router.Commands(m.GuildID), guildSettings.Prefix)
+	_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{renderHelpPage(pages, 0, guildSettings)},
+		Components: helpPageButtons(len(pages), 0),
+	})
+	if err != nil {
+		slog.Errorf("Error sending help: %v", err)
+	}
+}
+
+// Interactions handles the page buttons of the help.
+func (d *Discord) Interactions(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent || !d.guilds.Serves(i.GuildID) {
+		return
+	}
+
+	customID := i.MessageComponentData().CustomID
+	if !strings.HasPrefix(customID, helpPageButtonPrefix) {
+		return
+	}
+
+	page, err := strconv.Atoi(strings.TrimPrefix(customID, helpPageButtonPrefix))
+	if err != nil {
+		return
+	}
+
+	// the commands can change while the help is shown, keep the page in range
+	guildSettings := d.router.GuildSettings(i.GuildID)
+	pages := router.Overview(d.router.Commands(i.GuildID), guildSettings.Prefix)
+	page = max(0, min(page, len(pages)-1))
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{renderHelpPage(pages, page, guildSettings)},
+			Components: helpPageButtons(len(pages), page),
+		},
+	})
+	if err != nil {
+		slog.Errorf("Error responding to interaction: %v", err)
+	}
+}
+
+// renderHelpPage renders a page of the help overview.
+func renderHelpPage(pages [][]router.HelpField, page int, guildSettings settings.Settings) *discordgo.MessageEmbed {
+	footer := version.AppFullName
+	if len(pages) > 1 {
+		footer += fmt.Sprintf(" · page %d/%d", page+1, len(pages))
+	}
+
+	embedMsg := embed.NewEmbed().
+		SetTitle("ℹ️ Dice Roller — Command Usage").
+		SetDescription(fmt.Sprintf("Some commands are aliased for shortness. Use `%shelp <command>` for details and examples.\n", guildSettings.Prefix)).
+		SetThumbnail(avatarURL()).
+		SetColor(guildSettings.EmbedColor).SetFooter(footer)
+
+	if len(pages) > 0 {
+		for _, field := range pages[page] {
+			embedMsg.AddField("*"+field.Name+"*", field.Value)
+		}
+	}
+
+	return embedMsg.MessageEmbed
+}
+
+// renderCommandHelp renders the details and examples of a command.
+func renderCommandHelp(cmd *router.Command, guildSettings settings.Settings) *discordgo.MessageEmbed {
+	embedMsg := embed.NewEmbed().
+		SetTitle("ℹ️ " + guildSettings.Prefix + cmd.Name).
+		SetDescription(cmd.Description).
+		SetColor(guildSettings.EmbedColor).SetFooter(version.AppFullName)
+
+	for _, field := range cmd.Help(guildSettings.Prefix) {
+		embedMsg.AddField(field.Name, field.Value)
+	}
+
+	return embedMsg.MessageEmbed
+}
+
+// helpPageButtons returns the previous and next page buttons, none when the help fits on one page.
+func helpPageButtons(pages, page int) []discordgo.MessageComponent {
+	if pages < 2 {
+		return nil
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "◀ Previous",
+					Style:    discordgo.SecondaryButton,
+					CustomID: helpPageButtonPrefix + strconv.Itoa(page-1),
+					Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label:    "Next ▶",
+					Style:    discordgo.SecondaryButton,
+					CustomID: helpPageButtonPrefix + strconv.Itoa(page+1),
+					Disabled: page == pages-1,
+				},
+			},
+		},
+	}
+}
 
+// avatarURL returns the URL of a random avatar served by the REST API.
+func avatarURL() string {
 	config, err := config.NewConfig()
 	if err != nil {
 		slog.Fatalf("Error loading config: %v", err)
@@ -31,29 +157,5 @@ func (d *Discord) handleHelpCommand(s *discordgo.Session, m *discordgo.MessageCr
 		hostname = os.Getenv("HOST") // from docker environment
 	}
 
-	avatarUrl := utils.InferProtocolByPort(hostname, 443) + hostname + "/avatar/random?" + fmt.Sprint(time.Now().UnixNano())
-	slog.Info(avatarUrl)
-
-	prefix := d.CommandPrefix
-
-	rollShort := fmt.Sprintf("`%vroll` - default single roll of 1d20\n", prefix)
-	rollFull := fmt.Sprintf("`%vroll 2d20` - single roll\n", prefix)
-	rollMulti := fmt.Sprintf("`%vroll 1d20 2d6 1d4` - rolling several dice and adding up the result\n", prefix)
-	help := fmt.Sprintf("**Show help**: `%vhelp` \nAliases: `%vh`\n", prefix, prefix)
-	about := fmt.Sprintf("**Show version**: `%vabout`", prefix)
-	register := fmt.Sprintf("**Enable commands listening**: `%vregister`\n", prefix)
-	unregister := fmt.Sprintf("**Disable commands listening**: `%vunregister`", prefix)
-
-	embedMsg := embed.NewEmbed().
-		SetTitle("ℹ️ Dice Roller — Command Usage").
-		SetDescription("Some commands are aliased for shortness.\n").
-		AddField("", "*Rolls*\n"+rollShort+rollFull+rollMulti).
-		AddField("", "").
-		AddField("", "*General*\n"+help+about).
-		AddField("", "").
-		AddField("", "*Administration*\n"+register+unregister).
-		SetThumbnail(avatarUrl). // TODO: move out to config .env file
-		SetColor(0x9f00d4).SetFooter(version.AppFullName).MessageEmbed
-
-	s.ChannelMessageSendEmbed(m.Message.ChannelID, embedMsg)
+	return utils.InferProtocolByPort(hostname, 443) + hostname + "/avatar/random?" + fmt.Sprint(time.Now().UnixNano())
 }
@@ -0,0 +1,19 @@
+package discord
+
+import (
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/keshon/dice-roller/internal/botsdef"
+	"github.com/keshon/dice-roller/internal/router"
+)
+
+func init() {
+	botsdef.Register(botsdef.Plugin{
+		Name:           "about",
+		Version:        "1.0.0",
+		DefaultEnabled: true,
+		New: func(session *discordgo.Session, r *router.Router) botsdef.Discord {
+			return NewDiscord(session, r)
+		},
+	})
+}
@@ -71,7 +71,7 @@ func SanitizeString(input string) string {
 //
 // It takes a hostname of type string and a port of type int as parameters, and returns a string.
 func InferProtocolByPort(hostname string, port int) string {
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", hostname, port))
+	conn, err := net.Dial("tcp", net.JoinHostPort(hostname, strconv.Itoa(port)))
 	if err != nil {
 		return "http://"
 	}
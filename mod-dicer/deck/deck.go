@@ -0,0 +1,233 @@
+package deck
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+const (
+	Poker  = "poker"
+	Jokers = "jokers"
+	Tarot  = "tarot"
+	Custom = "custom"
+
+	// MaxCustomCards is the number of cards a custom deck can have.
+	MaxCustomCards = 200
+	// MaxDraw is the number of cards that can be drawn at once.
+	MaxDraw = 20
+)
+
+// Kinds are the kinds of built-in decks.
+var Kinds = []string{Poker, Jokers, Tarot}
+
+var (
+	suits      = []string{"♣", "♦", "♥", "♠"}
+	ranks      = []string{"2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A"}
+	jokerNames = []string{"Black Joker", "Red Joker"}
+
+	tarotMajor = []string{
+		"The Fool", "The Magician", "The High Priestess", "The Empress", "The Emperor", "The Hierophant",
+		"The Lovers", "The Chariot", "Strength", "The Hermit", "Wheel of Fortune", "Justice", "The Hanged Man",
+		"Death", "Temperance", "The Devil", "The Tower", "The Star", "The Moon", "The Sun", "Judgement", "The World",
+	}
+	tarotSuits = []string{"Wands", "Cups", "Swords", "Pentacles"}
+	tarotRanks = []string{"Ace", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten", "Page", "Knight", "Queen", "King"}
+)
+
+// Card is a drawn card.
+type Card struct {
+	Index    int  `json:"i"`
+	Reversed bool `json:"r,omitempty"`
+}
+
+// Deck is a deck of cards with its draw pile, the cards in play and the discard pile.
+// The top of the draw pile is its last card.
+type Deck struct {
+	Kind     string   `json:"kind"`
+	Names    []string `json:"names,omitempty"`
+	Pile     []int    `json:"pile"`
+	InPlay   []Card   `json:"inPlay,omitempty"`
+	Discards []int    `json:"discards,omitempty"`
+}
+
+// New creates a shuffled deck of the given kind. Custom decks take the names of their cards.
+func New(src dice.Source, kind string, names []string) (*Deck, error) {
+	d := &Deck{Kind: kind}
+
+	switch kind {
+	case Poker, Jokers, Tarot:
+	case Custom:
+		if len(names) == 0 {
+			return nil, fmt.Errorf("a custom deck needs cards, e.g. `[Ace, Two, Three]`")
+		}
+		if len(names) > MaxCustomCards {
+			return nil, fmt.Errorf("custom decks can have up to %d cards", MaxCustomCards)
+		}
+		d.Names = names
+	default:
+		return nil, fmt.Errorf("unknown deck %q, use %s or a `[card, card, ...]` list", kind, strings.Join(Kinds, ", "))
+	}
+
+	d.Shuffle(src)
+	return d, nil
+}
+
+// Load reads a deck saved with Save.
+func Load(state string) (*Deck, error) {
+	var d Deck
+	if err := json.Unmarshal([]byte(state), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Save writes the deck state as JSON.
+func (d *Deck) Save() (string, error) {
+	state, err := json.Marshal(d)
+	return string(state), err
+}
+
+// Size returns the number of cards in the deck.
+func (d *Deck) Size() int {
+	switch d.Kind {
+	case Poker:
+		return len(suits) * len(ranks)
+	case Jokers:
+		return len(suits)*len(ranks) + len(jokerNames)
+	case Tarot:
+		return len(tarotMajor) + len(tarotSuits)*len(tarotRanks)
+	}
+	return len(d.Names)
+}
+
+// Shuffle gathers every card, including those in play and discarded, and shuffles them into the draw pile.
+func (d *Deck) Shuffle(src dice.Source) {
+	d.Pile = make([]int, d.Size())
+	for i := range d.Pile {
+		d.Pile[i] = i
+	}
+	dice.Shuffle(src, d.Pile)
+	d.InPlay = nil
+	d.Discards = nil
+}
+
+// Draw takes cards from the top of the draw pile and puts them in play. Tarot cards can come out reversed.
+func (d *Deck) Draw(src dice.Source, n int) ([]Card, error) {
+	if n < 1 || n > MaxDraw {
+		return nil, fmt.Errorf("you can draw 1 to %d cards at once", MaxDraw)
+	}
+	if n > len(d.Pile) {
+		return nil, fmt.Errorf("only %d cards left, `shuffle` the deck first", len(d.Pile))
+	}
+
+	cards := make([]Card, n)
+	for i := range cards {
+		top := len(d.Pile) - 1
+		cards[i] = Card{Index: d.Pile[top]}
+		d.Pile = d.Pile[:top]
+		if d.Kind == Tarot {
+			cards[i].Reversed = src.Intn(2) == 1
+		}
+	}
+	d.InPlay = append(d.InPlay, cards...)
+	return cards, nil
+}
+
+// Discard moves the cards in play to the discard pile and returns how many there were.
+func (d *Deck) Discard() int {
+	n := len(d.InPlay)
+	for _, card := range d.InPlay {
+		d.Discards = append(d.Discards, card.Index)
+	}
+	d.InPlay = nil
+	return n
+}
+
+// Name returns the name of a card, e.g. "10♥", "Red Joker" or "The Tower (reversed)".
+func (d *Deck) Name(card Card) string {
+	var name string
+	switch d.Kind {
+	case Poker, Jokers:
+		name = standardName(card.Index)
+	case Tarot:
+		name = tarotName(card.Index)
+	default:
+		name = d.Names[card.Index]
+	}
+	if card.Reversed {
+		name += " (reversed)"
+	}
+	return name
+}
+
+// IsJoker reports whether the card is a joker.
+func (d *Deck) IsJoker(card Card) bool {
+	return d.Kind == Jokers && card.Index >= len(suits)*len(ranks)
+}
+
+// standardName names a card of a standard deck, ordered by rank then suit from 2♣ to A♠, then the jokers.
+func standardName(index int) string {
+	if index >= len(suits)*len(ranks) {
+		return jokerNames[index-len(suits)*len(ranks)]
+	}
+	return ranks[index/len(suits)] + suits[index%len(suits)]
+}
+
+// tarotName names a tarot card, the major arcana first.
+func tarotName(index int) string {
+	if index < len(tarotMajor) {
+		return tarotMajor[index]
+	}
+	index -= len(tarotMajor)
+	return tarotRanks[index%len(tarotRanks)] + " of " + tarotSuits[index/len(tarotRanks)]
+}
+
+// Deal is the cards dealt to one participant of an initiative deal.
+type Deal struct {
+	Name  string
+	Cards []Card
+	// Best is the card that sets the initiative order.
+	Best Card
+}
+
+// DealInitiative deals cards to each participant Deadlands-style and orders them from the highest card down.
+// Standard cards are ranked by value then by suit (spades, hearts, diamonds, clubs), and jokers come first.
+// counts gives how many cards each participant draws, keeping the best.
+func (d *Deck) DealInitiative(src dice.Source, names []string, counts []int) ([]Deal, error) {
+	if d.Kind != Poker && d.Kind != Jokers {
+		return nil, fmt.Errorf("initiative is dealt from a poker deck, start one with `deck new jokers`")
+	}
+
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	if total > len(d.Pile) {
+		return nil, fmt.Errorf("only %d cards left, `shuffle` the deck first", len(d.Pile))
+	}
+
+	deals := make([]Deal, len(names))
+	for i, name := range names {
+		cards, err := d.Draw(src, counts[i])
+		if err != nil {
+			return nil, err
+		}
+
+		deals[i] = Deal{Name: name, Cards: cards, Best: cards[0]}
+		for _, card := range cards[1:] {
+			if card.Index > deals[i].Best.Index {
+				deals[i].Best = card
+			}
+		}
+	}
+
+	// standard cards are indexed by rank then suit, and the jokers after them
+	sort.SliceStable(deals, func(a, b int) bool {
+		return deals[a].Best.Index > deals[b].Best.Index
+	})
+	return deals, nil
+}
@@ -0,0 +1,87 @@
+package deck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+func TestDraw(t *testing.T) {
+	d, err := New(dice.NewSeededSource(1), Poker, nil)
+	assert.NoError(t, err)
+	assert.Len(t, d.Pile, 52)
+
+	seen := make(map[int]bool)
+	for i := 0; i < 13; i++ {
+		cards, err := d.Draw(dice.Crypto, 4)
+		assert.NoError(t, err)
+		for _, card := range cards {
+			assert.False(t, seen[card.Index], "card drawn twice before a reshuffle")
+			seen[card.Index] = true
+		}
+	}
+
+	_, err = d.Draw(dice.Crypto, 1)
+	assert.Error(t, err)
+
+	assert.Equal(t, 52, d.Discard())
+	assert.Len(t, d.Discards, 52)
+
+	d.Shuffle(dice.Crypto)
+	assert.Len(t, d.Pile, 52)
+	assert.Empty(t, d.Discards)
+	assert.Empty(t, d.InPlay)
+}
+
+func TestNames(t *testing.T) {
+	jokers, _ := New(dice.Crypto, Jokers, nil)
+	assert.Equal(t, 54, jokers.Size())
+	assert.Equal(t, "2♣", jokers.Name(Card{Index: 0}))
+	assert.Equal(t, "A♠", jokers.Name(Card{Index: 51}))
+	assert.Equal(t, "Red Joker", jokers.Name(Card{Index: 53}))
+	assert.True(t, jokers.IsJoker(Card{Index: 52}))
+
+	tarot, _ := New(dice.Crypto, Tarot, nil)
+	assert.Equal(t, 78, tarot.Size())
+	assert.Equal(t, "The Fool", tarot.Name(Card{Index: 0}))
+	assert.Equal(t, "Ace of Wands", tarot.Name(Card{Index: 22}))
+	assert.Equal(t, "King of Pentacles (reversed)", tarot.Name(Card{Index: 77, Reversed: true}))
+
+	custom, err := New(dice.Crypto, Custom, []string{"Sun", "Moon"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Moon", custom.Name(Card{Index: 1}))
+
+	_, err = New(dice.Crypto, "uno", nil)
+	assert.Error(t, err)
+}
+
+func TestSaveLoad(t *testing.T) {
+	d, _ := New(dice.Crypto, Tarot, nil)
+	_, _ = d.Draw(dice.Crypto, 3)
+
+	state, err := d.Save()
+	assert.NoError(t, err)
+
+	loaded, err := Load(state)
+	assert.NoError(t, err)
+	assert.Equal(t, d, loaded)
+}
+
+func TestDealInitiative(t *testing.T) {
+	d := &Deck{Kind: Jokers, Pile: []int{0, 53, 12, 51, 8}}
+
+	deals, err := d.DealInitiative(dice.Crypto, []string{"alice", "bob", "goblins"}, []int{1, 2, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "goblins", deals[0].Name)
+	assert.True(t, d.IsJoker(deals[0].Best))
+	assert.Equal(t, "bob", deals[1].Name)
+	assert.Equal(t, "A♠", d.Name(deals[1].Best))
+	assert.Equal(t, "alice", deals[2].Name)
+	assert.Equal(t, Card{Index: 8}, deals[2].Best)
+
+	tarot := &Deck{Kind: Tarot, Pile: []int{1}}
+	_, err = tarot.DealInitiative(dice.Crypto, []string{"alice"}, []int{1})
+	assert.Error(t, err)
+}
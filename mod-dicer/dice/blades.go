@@ -0,0 +1,162 @@
+package dice
+
+import (
+	"fmt"
+)
+
+// MaxPool is the largest dice pool that can be rolled at once.
+const MaxPool = 10
+
+// Pool is a Forged in the Dark dice pool: the dice are not added up, only the best one counts.
+type Pool struct {
+	Dice []int
+	// Zero is set when the pool had no dice and two were rolled keeping the lowest.
+	Zero bool
+}
+
+// RollPool rolls n six-sided dice, or two dice keeping the lowest when n is zero.
+func RollPool(src Source, n int) (*Pool, error) {
+	if n < 0 || n > MaxPool {
+		return nil, fmt.Errorf("dice pool should be between 0 and %d dice", MaxPool)
+	}
+
+	pool := &Pool{Zero: n == 0}
+	if pool.Zero {
+		n = 2
+	}
+
+	for i := 0; i < n; i++ {
+		pool.Dice = append(pool.Dice, Roll(src, 6))
+	}
+	return pool, nil
+}
+
+// Result returns the die that counts: the highest, or the lowest for a zero dice pool.
+func (p *Pool) Result() int {
+	result := p.Dice[0]
+	for _, die := range p.Dice[1:] {
+		if (p.Zero && die < result) || (!p.Zero && die > result) {
+			result = die
+		}
+	}
+	return result
+}
+
+// Critical reports whether two or more sixes were rolled. A zero dice pool can't be critical.
+func (p *Pool) Critical() bool {
+	if p.Zero {
+		return false
+	}
+
+	sixes := 0
+	for _, die := range p.Dice {
+		if die == 6 {
+			sixes++
+		}
+	}
+	return sixes >= 2
+}
+
+// Outcome grades the pool: critical, 6 full success, 4-5 partial success, 1-3 failure.
+func (p *Pool) Outcome() PoolOutcome {
+	switch result := p.Result(); {
+	case p.Critical():
+		return PoolCritical
+	case result == 6:
+		return PoolFull
+	case result >= 4:
+		return PoolPartial
+	}
+	return PoolFailure
+}
+
+// Stress returns the stress cost of a resistance roll: 6 minus the result, or -1 (clear one stress) on a critical.
+func (p *Pool) Stress() int {
+	if p.Critical() {
+		return -1
+	}
+	return 6 - p.Result()
+}
+
+// PoolOutcome is the graded result of a dice pool.
+type PoolOutcome int
+
+const (
+	PoolFailure PoolOutcome = iota
+	PoolPartial
+	PoolFull
+	PoolCritical
+)
+
+// String returns the name of the outcome of an action roll.
+func (o PoolOutcome) String() string {
+	switch o {
+	case PoolFailure:
+		return "Bad outcome"
+	case PoolPartial:
+		return "Partial success"
+	case PoolFull:
+		return "Full success"
+	case PoolCritical:
+		return "Critical success"
+	}
+	return "Unknown"
+}
+
+// Fortune returns the name of the outcome of a fortune roll.
+func (o PoolOutcome) Fortune() string {
+	switch o {
+	case PoolFailure:
+		return "Poor result, limited effect"
+	case PoolPartial:
+		return "Standard result, standard effect"
+	case PoolFull:
+		return "Good result, great effect"
+	case PoolCritical:
+		return "Exceptional result"
+	}
+	return "Unknown"
+}
+
+// Positions and effects of Forged in the Dark action rolls, from safest to most dangerous and weakest to strongest.
+var (
+	Positions = []string{"controlled", "risky", "desperate"}
+	Effects   = []string{"zero", "limited", "standard", "great"}
+)
+
+// positionConsequences describes what each outcome means in each position.
+var positionConsequences = map[string]map[PoolOutcome]string{
+	"controlled": {
+		PoolCritical: "You do it with increased effect.",
+		PoolFull:     "You do it.",
+		PoolPartial:  "You hesitate: withdraw and try another way, or do it with a minor consequence.",
+		PoolFailure:  "You falter: press on by seizing a risky opportunity, or withdraw and try another way.",
+	},
+	"risky": {
+		PoolCritical: "You do it with increased effect.",
+		PoolFull:     "You do it.",
+		PoolPartial:  "You do it, but there's a consequence: harm, a complication, reduced effect or a desperate position.",
+		PoolFailure:  "Things go badly: harm, a complication, a desperate position or a lost opportunity.",
+	},
+	"desperate": {
+		PoolCritical: "You do it with increased effect.",
+		PoolFull:     "You do it.",
+		PoolPartial:  "You do it, but there's a consequence: severe harm, a serious complication or reduced effect.",
+		PoolFailure:  "It's the worst outcome: severe harm, a serious complication or a lost opportunity.",
+	},
+}
+
+// Consequence describes the outcome of an action roll in the given position.
+func Consequence(position string, outcome PoolOutcome) string {
+	return positionConsequences[position][outcome]
+}
+
+// IncreasedEffect returns the effect one level above the given one, capped at great.
+func IncreasedEffect(effect string) string {
+	for i, e := range Effects {
+		if e == effect && i+1 < len(Effects) {
+			return Effects[i+1]
+		}
+	}
+	return effect
+}
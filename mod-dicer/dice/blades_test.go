@@ -0,0 +1,38 @@
+package dice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		values  []int
+		result  int
+		outcome PoolOutcome
+		stress  int
+	}{
+		{"Critical", 3, []int{6, 2, 6}, 6, PoolCritical, -1},
+		{"Full", 2, []int{6, 3}, 6, PoolFull, 0},
+		{"Partial", 3, []int{1, 5, 4}, 5, PoolPartial, 1},
+		{"Failure", 1, []int{3}, 3, PoolFailure, 3},
+		{"ZeroDiceKeepsLowest", 0, []int{6, 6}, 6, PoolFull, 0},
+		{"ZeroDicePartial", 0, []int{6, 4}, 4, PoolPartial, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, err := RollPool(&fixedSource{values: tt.values}, tt.n)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.result, pool.Result())
+			assert.Equal(t, tt.outcome, pool.Outcome())
+			assert.Equal(t, tt.stress, pool.Stress())
+		})
+	}
+
+	_, err := RollPool(Crypto, MaxPool+1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,120 @@
+package dice
+
+import (
+	"fmt"
+)
+
+// Degree is the degree of success of a check.
+type Degree int
+
+const (
+	CriticalFailure Degree = iota
+	Failure
+	Success
+	CriticalSuccess
+)
+
+// String returns the human readable name of the degree.
+func (d Degree) String() string {
+	switch d {
+	case CriticalFailure:
+		return "Critical failure"
+	case Failure:
+		return "Failure"
+	case Success:
+		return "Success"
+	case CriticalSuccess:
+		return "Critical success"
+	}
+	return "Unknown"
+}
+
+// IsSuccess reports whether the degree counts as passing the check.
+func (d Degree) IsSuccess() bool {
+	return d >= Success
+}
+
+// Check compares the total of an expression with a difficulty class.
+// With Degrees set it follows Pathfinder 2e: beating the DC by 10 or more is a critical success,
+// missing it by 10 or more a critical failure, and a natural 20 or 1 steps the result up or down.
+type Check struct {
+	Target  int
+	Degrees bool
+}
+
+// String returns the notation of the check, e.g. "vs 15" or "dc25 pf2".
+func (c *Check) String() string {
+	if c.Degrees {
+		return fmt.Sprintf("dc%d pf2", c.Target)
+	}
+	return fmt.Sprintf("vs %d", c.Target)
+}
+
+// CheckResult is the outcome of a check.
+type CheckResult struct {
+	Check
+	Degree  Degree
+	Margin  int
+	Natural int
+}
+
+// resolve grades the rolled result against the check.
+func (c *Check) resolve(result *Result) *CheckResult {
+	cr := &CheckResult{Check: *c, Margin: result.Total - c.Target}
+
+	cr.Degree = Failure
+	if cr.Margin >= 0 {
+		cr.Degree = Success
+	}
+
+	if !c.Degrees {
+		return cr
+	}
+
+	switch {
+	case cr.Margin >= 10:
+		cr.Degree = CriticalSuccess
+	case cr.Margin <= -10:
+		cr.Degree = CriticalFailure
+	}
+
+	natural, ok := result.natural(20)
+	if !ok {
+		return cr
+	}
+	cr.Natural = natural
+
+	switch {
+	case natural == 20 && cr.Degree < CriticalSuccess:
+		cr.Degree++
+	case natural == 1 && cr.Degree > CriticalFailure:
+		cr.Degree--
+	}
+
+	return cr
+}
+
+// natural returns the face of the only die with the given sides, if exactly one such die was rolled.
+func (r *Result) natural(sides int) (int, bool) {
+	face, found := 0, false
+	for _, term := range r.Terms {
+		if !term.IsDice() || term.Sides != sides {
+			continue
+		}
+		if found || len(term.Rolls) != 1 {
+			return 0, false
+		}
+		face, found = term.Rolls[0], true
+	}
+	return face, found
+}
+
+// parseTarget reads the difficulty number following "vs" or "dc".
+func parseTarget(sc *scanner) (int, error) {
+	sc.skipSpaces()
+	target, ok := sc.number()
+	if !ok {
+		return 0, fmt.Errorf("difficulty should be a number, e.g. `vs 15` or `dc15`")
+	}
+	return target, nil
+}
@@ -0,0 +1,69 @@
+package dice
+
+import "fmt"
+
+const (
+	// MaxCoins is the number of coins that can be flipped at once.
+	MaxCoins = 100
+	// MaxChoices is the number of items that can be picked from, shuffled or split into teams.
+	MaxChoices = 100
+)
+
+// FlipCoins flips n coins, true is heads.
+func FlipCoins(src Source, n int) ([]bool, error) {
+	if n < 1 || n > MaxCoins {
+		return nil, fmt.Errorf("you can flip 1 to %d coins at once", MaxCoins)
+	}
+
+	coins := make([]bool, n)
+	for i := range coins {
+		coins[i] = src.Intn(2) == 0
+	}
+	return coins, nil
+}
+
+// Pick returns one of the items at random.
+func Pick(src Source, items []string) (string, error) {
+	if err := checkChoices(items, 1); err != nil {
+		return "", err
+	}
+	return items[src.Intn(len(items))], nil
+}
+
+// Shuffle shuffles the items in place with the Fisher-Yates algorithm.
+func Shuffle[T any](src Source, items []T) {
+	for i := len(items) - 1; i > 0; i-- {
+		j := src.Intn(i + 1)
+		items[i], items[j] = items[j], items[i]
+	}
+}
+
+// SplitTeams shuffles the items and deals them into n teams whose sizes differ by one at most.
+func SplitTeams(src Source, n int, items []string) ([][]string, error) {
+	if n < 2 {
+		return nil, fmt.Errorf("split into 2 teams or more")
+	}
+	if err := checkChoices(items, n); err != nil {
+		return nil, err
+	}
+
+	shuffled := append([]string(nil), items...)
+	Shuffle(src, shuffled)
+
+	teams := make([][]string, n)
+	for i, item := range shuffled {
+		teams[i%n] = append(teams[i%n], item)
+	}
+	return teams, nil
+}
+
+// checkChoices checks that there are between min and MaxChoices items.
+func checkChoices(items []string, min int) error {
+	if len(items) < min {
+		return fmt.Errorf("give at least %d items", min)
+	}
+	if len(items) > MaxChoices {
+		return fmt.Errorf("give up to %d items", MaxChoices)
+	}
+	return nil
+}
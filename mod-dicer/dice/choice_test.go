@@ -0,0 +1,41 @@
+package dice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlipCoins(t *testing.T) {
+	coins, err := FlipCoins(&fixedSource{values: []int{1, 2}}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, coins)
+
+	_, err = FlipCoins(Crypto, 0)
+	assert.Error(t, err)
+}
+
+func TestPick(t *testing.T) {
+	item, err := Pick(&fixedSource{values: []int{2}}, []string{"a", "b", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, "b", item)
+
+	_, err = Pick(Crypto, nil)
+	assert.Error(t, err)
+}
+
+func TestSplitTeams(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	teams, err := SplitTeams(NewSeededSource(7), 2, items)
+	assert.NoError(t, err)
+	assert.Len(t, teams, 2)
+	assert.Len(t, teams[0], 3)
+	assert.Len(t, teams[1], 2)
+	assert.ElementsMatch(t, items, append(teams[0], teams[1]...))
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, items, "the input is left untouched")
+
+	_, err = SplitTeams(Crypto, 3, []string{"a", "b"})
+	assert.Error(t, err)
+	_, err = SplitTeams(Crypto, 1, items)
+	assert.Error(t, err)
+}
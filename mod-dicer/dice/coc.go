@@ -0,0 +1,117 @@
+package dice
+
+import (
+	"fmt"
+)
+
+// MaxCoCExtraDice is the largest number of bonus or penalty dice in Call of Cthulhu 7e.
+const MaxCoCExtraDice = 2
+
+// CoCLevel is the graded result of a Call of Cthulhu skill roll.
+type CoCLevel int
+
+const (
+	CoCFumble CoCLevel = iota
+	CoCFailure
+	CoCRegularSuccess
+	CoCHardSuccess
+	CoCExtremeSuccess
+	CoCCriticalSuccess
+)
+
+// String returns the human readable name of the level.
+func (l CoCLevel) String() string {
+	switch l {
+	case CoCFumble:
+		return "Fumble"
+	case CoCFailure:
+		return "Failure"
+	case CoCRegularSuccess:
+		return "Regular success"
+	case CoCHardSuccess:
+		return "Hard success"
+	case CoCExtremeSuccess:
+		return "Extreme success"
+	case CoCCriticalSuccess:
+		return "Critical success"
+	}
+	return "Unknown"
+}
+
+// IsSuccess reports whether the level passes the skill roll.
+func (l CoCLevel) IsSuccess() bool {
+	return l >= CoCRegularSuccess
+}
+
+// CoCResult is a percentile roll against a Call of Cthulhu skill.
+type CoCResult struct {
+	Skill int
+	// Bonus is the net number of bonus dice, negative for penalty dice.
+	Bonus int
+	// Tens holds every tens die rolled, from 0 to 9.
+	Tens   []int
+	Units  int
+	Chosen int
+	Value  int
+	Level  CoCLevel
+}
+
+// RollCoC rolls percentile dice against a skill value with bonus (positive) or penalty (negative) dice.
+// Each extra tens die is rolled alongside the first and the best (bonus) or worst (penalty) result is kept.
+func RollCoC(src Source, skill, bonus int) (*CoCResult, error) {
+	if skill < 1 || skill > 100 {
+		return nil, fmt.Errorf("skill value should be between 1 and 100")
+	}
+	if bonus > MaxCoCExtraDice || bonus < -MaxCoCExtraDice {
+		return nil, fmt.Errorf("up to %d bonus or penalty dice can be rolled", MaxCoCExtraDice)
+	}
+
+	result := &CoCResult{Skill: skill, Bonus: bonus, Units: src.Intn(10)}
+
+	extra := bonus
+	if extra < 0 {
+		extra = -extra
+	}
+
+	for i := 0; i <= extra; i++ {
+		tens := src.Intn(10)
+		result.Tens = append(result.Tens, tens)
+
+		value := percentile(tens, result.Units)
+		better := bonus >= 0 && value < result.Value
+		worse := bonus < 0 && value > result.Value
+		if i == 0 || better || worse {
+			result.Chosen = i
+			result.Value = value
+		}
+	}
+
+	result.Level = gradeCoC(result.Value, skill)
+	return result, nil
+}
+
+// percentile combines a tens and a units die, where 00 and 0 read as 100.
+func percentile(tens, units int) int {
+	value := tens*10 + units
+	if value == 0 {
+		return 100
+	}
+	return value
+}
+
+// gradeCoC grades a percentile value against a skill following the Keeper Rulebook.
+func gradeCoC(value, skill int) CoCLevel {
+	switch {
+	case value == 1:
+		return CoCCriticalSuccess
+	case value == 100 || (skill < 50 && value >= 96):
+		return CoCFumble
+	case value <= skill/5:
+		return CoCExtremeSuccess
+	case value <= skill/2:
+		return CoCHardSuccess
+	case value <= skill:
+		return CoCRegularSuccess
+	}
+	return CoCFailure
+}
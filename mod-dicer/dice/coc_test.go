@@ -0,0 +1,51 @@
+package dice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollCoC(t *testing.T) {
+	t.Run("BonusKeepsLowestTens", func(t *testing.T) {
+		// units 3, tens 7 and 2
+		result, err := RollCoC(&fixedSource{values: []int{4, 8, 3}}, 45, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{7, 2}, result.Tens)
+		assert.Equal(t, 23, result.Value)
+		assert.Equal(t, CoCRegularSuccess, result.Level)
+	})
+
+	t.Run("PenaltyKeepsHighestTens", func(t *testing.T) {
+		result, err := RollCoC(&fixedSource{values: []int{4, 3, 8}}, 45, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, 73, result.Value)
+		assert.Equal(t, 1, result.Chosen)
+		assert.Equal(t, CoCFailure, result.Level)
+	})
+
+	t.Run("DoubleZeroIsHundred", func(t *testing.T) {
+		result, err := RollCoC(&fixedSource{values: []int{1, 1}}, 80, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 100, result.Value)
+		assert.Equal(t, CoCFumble, result.Level)
+	})
+
+	t.Run("InvalidInput", func(t *testing.T) {
+		_, err := RollCoC(Crypto, 0, 0)
+		assert.Error(t, err)
+		_, err = RollCoC(Crypto, 50, 3)
+		assert.Error(t, err)
+	})
+}
+
+func TestGradeCoC(t *testing.T) {
+	assert.Equal(t, CoCCriticalSuccess, gradeCoC(1, 45))
+	assert.Equal(t, CoCExtremeSuccess, gradeCoC(9, 45))
+	assert.Equal(t, CoCHardSuccess, gradeCoC(22, 45))
+	assert.Equal(t, CoCRegularSuccess, gradeCoC(45, 45))
+	assert.Equal(t, CoCFailure, gradeCoC(46, 45))
+	assert.Equal(t, CoCFumble, gradeCoC(96, 45))
+	assert.Equal(t, CoCFailure, gradeCoC(96, 60))
+	assert.Equal(t, CoCFumble, gradeCoC(100, 60))
+}
@@ -0,0 +1,457 @@
+package dice
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxTerms = 10
+	MaxDice  = 10
+	MaxSides = 100
+)
+
+// Term is a single part of a dice expression: a group of dice or a flat modifier.
+type Term struct {
+	Sign       int
+	Count      int
+	Sides      int
+	Fate       bool
+	Keep       int
+	KeepLowest bool
+	Value      int
+	Rolls      []int
+	Kept       []bool
+	// Custom is the name of a custom-face die, resolved into FaceDie before rolling.
+	Custom    string
+	FaceDie   *FaceDie
+	FaceRolls []FaceRoll
+}
+
+// IsDice reports whether the term rolls dice rather than adding a flat modifier.
+func (t Term) IsDice() bool {
+	return t.Count > 0
+}
+
+// Total returns the signed contribution of the term to the expression total.
+func (t Term) Total() int {
+	if !t.IsDice() {
+		return t.Sign * t.Value
+	}
+
+	total := 0
+	for i, roll := range t.Rolls {
+		if t.isKept(i) {
+			total += roll
+		}
+	}
+	return t.Sign * total
+}
+
+// isKept reports whether the i-th die counts towards the total.
+func (t Term) isKept(i int) bool {
+	return t.Kept == nil || t.Kept[i]
+}
+
+// String returns the notation of the term without its sign, e.g. "2d6" or "5".
+func (t Term) String() string {
+	switch {
+	case !t.IsDice():
+		return strconv.Itoa(t.Value)
+	case t.Custom != "":
+		return fmt.Sprintf("%dd{%s}", t.Count, t.Custom)
+	case t.Fate:
+		return fmt.Sprintf("%ddF", t.Count)
+	case t.Keep > 0 && t.KeepLowest:
+		return fmt.Sprintf("%dd%dkl%d", t.Count, t.Sides, t.Keep)
+	case t.Keep > 0:
+		return fmt.Sprintf("%dd%dkh%d", t.Count, t.Sides, t.Keep)
+	}
+	return fmt.Sprintf("%dd%d", t.Count, t.Sides)
+}
+
+// Faces returns how each rolled die is shown: its number, or a symbol for Fate dice.
+// Dropped dice are struck through.
+func (t Term) Faces() []string {
+	if t.Custom != "" {
+		faces := make([]string, len(t.FaceRolls))
+		for i, roll := range t.FaceRolls {
+			faces[i] = roll.Face.Label
+		}
+		return faces
+	}
+
+	faces := make([]string, len(t.Rolls))
+	for i, roll := range t.Rolls {
+		if t.Fate {
+			faces[i] = FateSymbol(roll)
+		} else {
+			faces[i] = strconv.Itoa(roll)
+		}
+		if !t.isKept(i) {
+			faces[i] = "~~" + faces[i] + "~~"
+		}
+	}
+	return faces
+}
+
+// Expression is a parsed dice expression with an optional difficulty check.
+type Expression struct {
+	Terms []Term
+	Check *Check
+}
+
+// String returns the canonical notation of the expression, e.g. "1d20+5 vs 15".
+func (e *Expression) String() string {
+	notation := Format(e.Terms)
+	if e.Check != nil {
+		notation += " " + e.Check.String()
+	}
+	return notation
+}
+
+// Result is an evaluated dice expression.
+type Result struct {
+	Expression string
+	Terms      []Term
+	Total      int
+	Check      *CheckResult
+}
+
+// HasFate reports whether any Fate dice were rolled.
+func (r *Result) HasFate() bool {
+	for _, term := range r.Terms {
+		if term.Fate {
+			return true
+		}
+	}
+	return false
+}
+
+// DiceCount returns the number of dice rolled in the expression.
+func (r *Result) DiceCount() int {
+	count := 0
+	for _, term := range r.Terms {
+		count += len(term.Rolls)
+	}
+	return count
+}
+
+// Tally counts the symbolic faces of the custom dice rolled in the expression.
+func (r *Result) Tally() []SymbolCount {
+	var rolls []FaceRoll
+	for _, term := range r.Terms {
+		rolls = append(rolls, term.FaceRolls...)
+	}
+	return CountSymbols(rolls)
+}
+
+// Roller evaluates dice expressions against a random source.
+type Roller struct {
+	Source Source
+	// Faces looks up custom-face dice by name, it may be nil when no custom dice are available.
+	Faces func(name string) (*FaceDie, error)
+}
+
+// NewRoller creates a new Roller using the given random source.
+func NewRoller(src Source) *Roller {
+	return &Roller{Source: src}
+}
+
+// Evaluate parses and rolls a dice expression such as "1d20+5", "2d6 1d4 - 1" or "1d20+4 vs 15".
+// Terms separated only by whitespace are added up.
+func (r *Roller) Evaluate(expr string) (*Result, error) {
+	parsed, err := Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Resolve(parsed); err != nil {
+		return nil, err
+	}
+
+	return r.Roll(parsed), nil
+}
+
+// WithFaces returns a copy of the roller that looks up custom-face dice with the given function.
+func (r *Roller) WithFaces(faces func(name string) (*FaceDie, error)) *Roller {
+	return &Roller{Source: r.Source, Faces: faces}
+}
+
+// Resolve looks up the custom-face dice used in the expression.
+func (r *Roller) Resolve(expr *Expression) error {
+	for i, term := range expr.Terms {
+		if term.Custom == "" {
+			continue
+		}
+		if r.Faces == nil {
+			return fmt.Errorf("custom dice are not available here")
+		}
+
+		die, err := r.Faces(term.Custom)
+		if err != nil {
+			return err
+		}
+		expr.Terms[i].FaceDie = die
+	}
+	return nil
+}
+
+// Roll rolls an already parsed and resolved expression.
+func (r *Roller) Roll(expr *Expression) *Result {
+	result := &Result{Expression: expr.String()}
+	for _, term := range expr.Terms {
+		if term.FaceDie != nil {
+			term.FaceRolls = make([]FaceRoll, term.Count)
+			term.Rolls = make([]int, term.Count)
+			for i := range term.FaceRolls {
+				term.FaceRolls[i] = FaceRoll{Die: term.FaceDie, Face: term.FaceDie.Roll(r.Source)}
+				term.Rolls[i] = term.FaceRolls[i].Face.Value
+			}
+		} else if term.IsDice() {
+			term.Rolls = make([]int, term.Count)
+			for i := range term.Rolls {
+				if term.Fate {
+					term.Rolls[i] = rollFate(r.Source)
+				} else {
+					term.Rolls[i] = Roll(r.Source, term.Sides)
+				}
+			}
+			if term.Keep > 0 {
+				term.Kept = keepDice(term.Rolls, term.Keep, term.KeepLowest)
+			}
+		}
+		result.Terms = append(result.Terms, term)
+		result.Total += term.Total()
+	}
+
+	if expr.Check != nil {
+		result.Check = expr.Check.resolve(result)
+	}
+
+	return result
+}
+
+// Parse splits a dice expression into terms and an optional check without rolling them.
+func Parse(expr string) (*Expression, error) {
+	sc := &scanner{input: strings.ToLower(strings.TrimSpace(expr))}
+	if sc.done() {
+		return nil, fmt.Errorf("empty dice expression")
+	}
+
+	var terms []Term
+	var check *Check
+	for {
+		sc.skipSpaces()
+		if sc.done() {
+			break
+		}
+
+		if check != nil {
+			if !check.Degrees && sc.consumeWord("pf2") {
+				check.Degrees = true
+				continue
+			}
+			return nil, fmt.Errorf("unexpected %q after the difficulty", sc.rest())
+		}
+
+		if len(terms) > 0 && (sc.consumeWord("vs") || sc.consume("dc")) {
+			target, err := parseTarget(sc)
+			if err != nil {
+				return nil, err
+			}
+			check = &Check{Target: target}
+			continue
+		}
+
+		term, err := parseTerm(sc, len(terms) == 0)
+		if err != nil {
+			return nil, err
+		}
+
+		terms = append(terms, term)
+		if len(terms) > MaxTerms {
+			return nil, fmt.Errorf("you can roll up to %d dice groups in a single command", MaxTerms)
+		}
+	}
+
+	return &Expression{Terms: terms, Check: check}, nil
+}
+
+// Format returns the canonical notation of the terms, e.g. "1d20+5".
+func Format(terms []Term) string {
+	var sb strings.Builder
+	for i, term := range terms {
+		switch {
+		case term.Sign < 0:
+			sb.WriteString("-")
+		case i > 0:
+			sb.WriteString("+")
+		}
+		sb.WriteString(term.String())
+	}
+	return sb.String()
+}
+
+// parseTerm reads one optionally signed term from the scanner.
+func parseTerm(sc *scanner, first bool) (Term, error) {
+	term := Term{Sign: 1}
+
+	if sc.consume("-") {
+		term.Sign = -1
+	} else if !sc.consume("+") && !first && !sc.afterSpace() {
+		return term, fmt.Errorf("unexpected %q in dice expression", sc.rest())
+	}
+	sc.skipSpaces()
+
+	count, hasCount := sc.number()
+
+	if !sc.consume("d") {
+		if !hasCount {
+			return term, fmt.Errorf("unexpected %q in dice expression", sc.rest())
+		}
+		term.Value = count
+		return term, nil
+	}
+
+	if sc.consume("f") {
+		if !hasCount {
+			count = FateDice
+		}
+		term.Fate = true
+	} else if !hasCount {
+		count = 1
+	}
+	if count <= 0 || count > MaxDice {
+		return term, fmt.Errorf("number of dice should be between 1 and %d", MaxDice)
+	}
+
+	if term.Fate {
+		term.Count = count
+		return term, nil
+	}
+
+	if sc.consume("{") {
+		end := strings.IndexByte(sc.rest(), '}')
+		if end < 0 {
+			return term, fmt.Errorf("missing `}` after the custom die name")
+		}
+		term.Count = count
+		term.Custom = sc.rest()[:end]
+		sc.pos += end + 1
+		return term, nil
+	}
+
+	sides, ok := sc.number()
+	if !ok && sc.consume("%") {
+		sides, ok = 100, true
+	}
+	if !ok {
+		return term, fmt.Errorf("invalid dice sides in %q", sc.input)
+	}
+	if sides <= 0 || sides > MaxSides {
+		return term, fmt.Errorf("dice sides should be between 1 and %d", MaxSides)
+	}
+
+	term.Count = count
+	term.Sides = sides
+
+	switch {
+	case sc.consume("kl"):
+		term.KeepLowest = true
+	case sc.consume("kh"), sc.consume("k"):
+	default:
+		return term, nil
+	}
+
+	term.Keep, ok = sc.number()
+	if !ok || term.Keep < 1 || term.Keep > count {
+		return term, fmt.Errorf("number of kept dice should be between 1 and %d", count)
+	}
+
+	return term, nil
+}
+
+// keepDice marks the highest (or lowest) dice to be kept.
+func keepDice(rolls []int, keep int, lowest bool) []bool {
+	order := make([]int, len(rolls))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if lowest {
+			return rolls[order[a]] < rolls[order[b]]
+		}
+		return rolls[order[a]] > rolls[order[b]]
+	})
+
+	kept := make([]bool, len(rolls))
+	for _, i := range order[:keep] {
+		kept[i] = true
+	}
+	return kept
+}
+
+// scanner walks over a lowercased dice expression.
+type scanner struct {
+	input string
+	pos   int
+}
+
+func (sc *scanner) done() bool {
+	return sc.pos >= len(sc.input)
+}
+
+func (sc *scanner) rest() string {
+	return sc.input[sc.pos:]
+}
+
+func (sc *scanner) skipSpaces() {
+	for !sc.done() && (sc.input[sc.pos] == ' ' || sc.input[sc.pos] == '\t') {
+		sc.pos++
+	}
+}
+
+// afterSpace reports whether the current position directly follows whitespace.
+func (sc *scanner) afterSpace() bool {
+	return sc.pos > 0 && (sc.input[sc.pos-1] == ' ' || sc.input[sc.pos-1] == '\t')
+}
+
+func (sc *scanner) consume(prefix string) bool {
+	if strings.HasPrefix(sc.rest(), prefix) {
+		sc.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// consumeWord consumes the word only when it is not directly followed by more letters.
+func (sc *scanner) consumeWord(word string) bool {
+	rest := sc.rest()
+	if !strings.HasPrefix(rest, word) {
+		return false
+	}
+	if len(rest) > len(word) && rest[len(word)] >= 'a' && rest[len(word)] <= 'z' {
+		return false
+	}
+	sc.pos += len(word)
+	return true
+}
+
+func (sc *scanner) number() (int, bool) {
+	start := sc.pos
+	for !sc.done() && sc.input[sc.pos] >= '0' && sc.input[sc.pos] <= '9' {
+		sc.pos++
+	}
+	if start == sc.pos {
+		return 0, false
+	}
+
+	value, err := strconv.Atoi(sc.input[start:sc.pos])
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
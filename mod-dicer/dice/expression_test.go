@@ -0,0 +1,145 @@
+package dice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedSource returns the queued values in order, repeating the last one.
+type fixedSource struct {
+	values []int
+}
+
+func (f *fixedSource) Intn(n int) int {
+	v := f.values[0]
+	if len(f.values) > 1 {
+		f.values = f.values[1:]
+	}
+	return (v - 1) % n
+}
+
+func TestParse(t *testing.T) {
+	t.Run("DiceAndModifiers", func(t *testing.T) {
+		expr, err := Parse("2d6 + d4-3")
+		assert.NoError(t, err)
+		assert.Equal(t, "2d6+1d4-3", expr.String())
+	})
+
+	t.Run("WhitespaceAddsTerms", func(t *testing.T) {
+		expr, err := Parse("1d20 2d6 1d4")
+		assert.NoError(t, err)
+		assert.Len(t, expr.Terms, 3)
+		assert.Equal(t, "1d20+2d6+1d4", expr.String())
+	})
+
+	t.Run("Check", func(t *testing.T) {
+		expr, err := Parse("1d20+4 vs 15")
+		assert.NoError(t, err)
+		assert.Equal(t, &Check{Target: 15}, expr.Check)
+
+		expr, err = Parse("1d20+7 DC25 pf2")
+		assert.NoError(t, err)
+		assert.Equal(t, &Check{Target: 25, Degrees: true}, expr.Check)
+	})
+
+	t.Run("Fate", func(t *testing.T) {
+		expr, err := Parse("dF")
+		assert.NoError(t, err)
+		assert.Equal(t, "4dF", expr.String())
+
+		expr, err = Parse("4dF+2")
+		assert.NoError(t, err)
+		assert.Equal(t, "4dF+2", expr.String())
+	})
+
+	t.Run("Percentile", func(t *testing.T) {
+		expr, err := Parse("d%+5")
+		assert.NoError(t, err)
+		assert.Equal(t, "1d100+5", expr.String())
+	})
+
+	t.Run("Keep", func(t *testing.T) {
+		expr, err := Parse("3d6k2 + 4d6kl3")
+		assert.NoError(t, err)
+		assert.Equal(t, "3d6kh2+4d6kl3", expr.String())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, expr := range []string{"", "abc", "1d", "11d6", "1d101", "1d20x", "0d6", "vs 15", "1d20 vs", "1d20 dc15 2d6", "2d6kh3", "3d6kl0"} {
+			_, err := Parse(expr)
+			assert.Error(t, err, expr)
+		}
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	roller := NewRoller(&fixedSource{values: []int{4, 2, 17}})
+
+	result, err := roller.Evaluate("2d6+1d20-3")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{4, 2}, result.Terms[0].Rolls)
+	assert.Equal(t, []int{17}, result.Terms[1].Rolls)
+	assert.Equal(t, 20, result.Total)
+	assert.Equal(t, 3, result.DiceCount())
+}
+
+func TestKeep(t *testing.T) {
+	roller := NewRoller(&fixedSource{values: []int{5, 1, 5, 2, 6, 3}})
+
+	result, err := roller.Evaluate("3d6kh2 3d6kl1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"5", "~~1~~", "5"}, result.Terms[0].Faces())
+	assert.Equal(t, []string{"2", "~~6~~", "~~3~~"}, result.Terms[1].Faces())
+	assert.Equal(t, 12, result.Total)
+}
+
+func TestFate(t *testing.T) {
+	roller := NewRoller(&fixedSource{values: []int{3, 3, 1, 2}})
+
+	result, err := roller.Evaluate("4dF+2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"+", "+", "−", "☐"}, result.Terms[0].Faces())
+	assert.Equal(t, 3, result.Total)
+	assert.True(t, result.HasFate())
+
+	assert.Equal(t, "Great (+4)", FateLadder(4))
+	assert.Equal(t, "Terrible (-2)", FateLadder(-2))
+	assert.Equal(t, "Beyond Legendary (+10)", FateLadder(10))
+}
+
+func TestSeededSource(t *testing.T) {
+	a, b := NewSeededSource(42), NewSeededSource(42)
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, a.Intn(100), b.Intn(100))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		roll   int
+		expr   string
+		degree Degree
+		margin int
+	}{
+		{"Success", 11, "1d20+4 vs 15", Success, 0},
+		{"Failure", 10, "1d20+4 dc15", Failure, -1},
+		{"NoNaturalSteppingWithoutPF2", 20, "1d20 vs 30", Failure, -10},
+		{"PF2CriticalSuccess", 18, "1d20+7 dc15 pf2", CriticalSuccess, 10},
+		{"PF2CriticalFailure", 2, "1d20 dc15 pf2", CriticalFailure, -13},
+		{"PF2Natural20StepsUp", 20, "1d20 dc25 pf2", Success, -5},
+		{"PF2Natural1StepsDown", 1, "1d20+20 dc15 pf2", Failure, 6},
+		{"PF2Natural20CappedAtCritical", 20, "1d20+5 dc15 pf2", CriticalSuccess, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roller := NewRoller(&fixedSource{values: []int{tt.roll}})
+			result, err := roller.Evaluate(tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.degree, result.Check.Degree)
+			assert.Equal(t, tt.margin, result.Check.Margin)
+		})
+	}
+}
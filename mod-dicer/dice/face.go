@@ -0,0 +1,162 @@
+package dice
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxFaces       = 100
+	MaxFaceLabel   = 50
+	MaxFaceWeight  = 100
+	MaxFaceDieName = 32
+)
+
+var faceDieNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// Face is one side of a custom-face die. A face can add a number to the total,
+// carry symbols that are counted up, or both.
+type Face struct {
+	Label   string
+	Value   int
+	Symbols map[string]int
+	// Weight is the relative chance of the face, 0 counts as 1.
+	Weight int
+}
+
+// weight returns the effective weight of the face.
+func (f Face) weight() int {
+	if f.Weight <= 0 {
+		return 1
+	}
+	return f.Weight
+}
+
+// FaceDie is a die with arbitrary faces, such as narrative dice or homebrew tables.
+type FaceDie struct {
+	Name  string
+	Faces []Face
+}
+
+// Roll rolls the die, taking face weights into account.
+func (d *FaceDie) Roll(src Source) Face {
+	total := 0
+	for _, face := range d.Faces {
+		total += face.weight()
+	}
+
+	pick := src.Intn(total)
+	for _, face := range d.Faces {
+		if pick < face.weight() {
+			return face
+		}
+		pick -= face.weight()
+	}
+
+	return d.Faces[len(d.Faces)-1]
+}
+
+// Validate checks that the die can be rolled.
+func (d *FaceDie) Validate() error {
+	if len(d.Faces) == 0 {
+		return fmt.Errorf("die %q has no faces", d.Name)
+	}
+	for _, face := range d.Faces {
+		if face.Weight < 0 {
+			return fmt.Errorf("face %q of die %q has a negative weight", face.Label, d.Name)
+		}
+	}
+	return nil
+}
+
+// FaceRoll is a rolled custom-face die.
+type FaceRoll struct {
+	Die  *FaceDie
+	Face Face
+}
+
+// RollFaces rolls every die once.
+func RollFaces(src Source, dice []*FaceDie) []FaceRoll {
+	rolls := make([]FaceRoll, len(dice))
+	for i, die := range dice {
+		rolls[i] = FaceRoll{Die: die, Face: die.Roll(src)}
+	}
+	return rolls
+}
+
+// SymbolCount is the number of times a symbol came up.
+type SymbolCount struct {
+	Symbol string
+	Count  int
+}
+
+// CountSymbols adds up the symbols of the rolled faces, ordered by symbol name.
+func CountSymbols(rolls []FaceRoll) []SymbolCount {
+	counts := make(map[string]int)
+	for _, roll := range rolls {
+		for symbol, n := range roll.Face.Symbols {
+			counts[symbol] += n
+		}
+	}
+
+	result := make([]SymbolCount, 0, len(counts))
+	for symbol, n := range counts {
+		result = append(result, SymbolCount{Symbol: symbol, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
+
+// ParseFaceList builds a die from a face list such as "[copper, copper, silver, gold:2]".
+// Faces that are whole numbers add to the total, any other face is counted as a symbol.
+// A ":N" suffix gives the face a weight of N.
+func ParseFaceList(name, list string) (*FaceDie, error) {
+	name = strings.ToLower(name)
+	if len(name) > MaxFaceDieName || !faceDieNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("die names start with a letter and use up to %d letters, digits, `-` or `_`", MaxFaceDieName)
+	}
+
+	list = strings.TrimSpace(list)
+	list = strings.TrimSuffix(strings.TrimPrefix(list, "["), "]")
+
+	die := &FaceDie{Name: name}
+	for _, item := range strings.Split(list, ",") {
+		label := strings.TrimSpace(item)
+		weight := 0
+
+		if i := strings.LastIndex(label, ":"); i >= 0 {
+			w, err := strconv.Atoi(strings.TrimSpace(label[i+1:]))
+			if err != nil || w < 1 || w > MaxFaceWeight {
+				return nil, fmt.Errorf("weight of %q should be between 1 and %d", label, MaxFaceWeight)
+			}
+			weight = w
+			label = strings.TrimSpace(label[:i])
+		}
+
+		if label == "" {
+			return nil, fmt.Errorf("faces can't be empty")
+		}
+		if len(label) > MaxFaceLabel {
+			return nil, fmt.Errorf("face %q is longer than %d characters", label, MaxFaceLabel)
+		}
+
+		face := Face{Label: label, Weight: weight}
+		if value, err := strconv.Atoi(label); err == nil {
+			face.Value = value
+		} else {
+			face.Symbols = map[string]int{label: 1}
+		}
+		die.Faces = append(die.Faces, face)
+	}
+
+	if len(die.Faces) > MaxFaces {
+		return nil, fmt.Errorf("a die can have up to %d faces", MaxFaces)
+	}
+
+	return die, die.Validate()
+}
@@ -0,0 +1,50 @@
+package dice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaceDieWeights(t *testing.T) {
+	die := &FaceDie{Name: "loot", Faces: []Face{{Label: "copper", Weight: 3}, {Label: "gold"}}}
+	assert.NoError(t, die.Validate())
+
+	for value, label := range map[int]string{1: "copper", 3: "copper", 4: "gold"} {
+		assert.Equal(t, label, die.Roll(&fixedSource{values: []int{value}}).Label)
+	}
+}
+
+func TestParseFaceList(t *testing.T) {
+	die, err := ParseFaceList("Loot", "[copper, Silver:2, 5]")
+	assert.NoError(t, err)
+	assert.Equal(t, "loot", die.Name)
+	assert.Equal(t, Face{Label: "copper", Symbols: map[string]int{"copper": 1}}, die.Faces[0])
+	assert.Equal(t, Face{Label: "Silver", Weight: 2, Symbols: map[string]int{"Silver": 1}}, die.Faces[1])
+	assert.Equal(t, Face{Label: "5", Value: 5}, die.Faces[2])
+
+	for _, list := range []string{"[]", "[a, ]", "[a:0]", "[a:x]"} {
+		_, err := ParseFaceList("loot", list)
+		assert.Error(t, err, list)
+	}
+
+	_, err = ParseFaceList("9lives", "[a]")
+	assert.Error(t, err)
+}
+
+func TestEvaluateCustomDice(t *testing.T) {
+	loot, _ := ParseFaceList("loot", "[copper, gold, 10]")
+	roller := NewRoller(&fixedSource{values: []int{1, 3, 1}}).WithFaces(func(name string) (*FaceDie, error) {
+		return loot, nil
+	})
+
+	result, err := roller.Evaluate("3d{loot}+2")
+	assert.NoError(t, err)
+	assert.Equal(t, "3d{loot}+2", result.Expression)
+	assert.Equal(t, []string{"copper", "10", "copper"}, result.Terms[0].Faces())
+	assert.Equal(t, 12, result.Total)
+	assert.Equal(t, []SymbolCount{{Symbol: "copper", Count: 2}}, result.Tally())
+
+	_, err = NewRoller(Crypto).Evaluate("1d{loot}")
+	assert.Error(t, err)
+}
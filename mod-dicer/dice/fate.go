@@ -0,0 +1,58 @@
+package dice
+
+import (
+	"fmt"
+)
+
+// FateDice is the number of Fate dice rolled when "dF" is given without a count.
+const FateDice = 4
+
+// fateLadder maps Fate Core ladder values to their adjectives.
+var fateLadder = map[int]string{
+	8:  "Legendary",
+	7:  "Epic",
+	6:  "Fantastic",
+	5:  "Superb",
+	4:  "Great",
+	3:  "Good",
+	2:  "Fair",
+	1:  "Average",
+	0:  "Mediocre",
+	-1: "Poor",
+	-2: "Terrible",
+}
+
+// FateSymbol returns the face of a Fate die: "+", "−" or a blank box.
+func FateSymbol(value int) string {
+	switch {
+	case value > 0:
+		return "+"
+	case value < 0:
+		return "−"
+	}
+	return "☐"
+}
+
+// FateLadder translates a total to the Fate ladder, e.g. "Great (+4)".
+// Results beyond the ladder keep the name of its last rung.
+func FateLadder(total int) string {
+	rung := total
+	switch {
+	case rung > 8:
+		rung = 8
+	case rung < -2:
+		rung = -2
+	}
+
+	name := fateLadder[rung]
+	if rung != total {
+		name = "Beyond " + name
+	}
+
+	return fmt.Sprintf("%s (%+d)", name, total)
+}
+
+// rollFate rolls a single Fate die, returning -1, 0 or +1.
+func rollFate(src Source) int {
+	return src.Intn(3) - 1
+}
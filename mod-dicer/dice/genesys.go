@@ -0,0 +1,193 @@
+package dice
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Genesys symbols.
+const (
+	SymbolSuccess   = "success"
+	SymbolFailure   = "failure"
+	SymbolAdvantage = "advantage"
+	SymbolThreat    = "threat"
+	SymbolTriumph   = "triumph"
+	SymbolDespair   = "despair"
+	SymbolLight     = "light"
+	SymbolDark      = "dark"
+)
+
+// GenesysIcons maps Genesys symbols and dice to the emoji they are shown with.
+var GenesysIcons = map[string]string{
+	SymbolSuccess:   "✳️",
+	SymbolFailure:   "✖️",
+	SymbolAdvantage: "⏫",
+	SymbolThreat:    "⏬",
+	SymbolTriumph:   "🏆",
+	SymbolDespair:   "💀",
+	SymbolLight:     "⚪",
+	SymbolDark:      "⚫",
+	"boost":         "🟦",
+	"setback":       "⬛",
+	"ability":       "🟩",
+	"difficulty":    "🟪",
+	"proficiency":   "🟨",
+	"challenge":     "🟥",
+	"force":         "⬜",
+}
+
+// genesysFace builds a face from its symbols, e.g. genesysFace("s", "a") for success and advantage.
+func genesysFace(codes ...string) Face {
+	names := map[string]string{
+		"s": SymbolSuccess, "f": SymbolFailure, "a": SymbolAdvantage, "t": SymbolThreat,
+		"tr": SymbolTriumph, "de": SymbolDespair, "l": SymbolLight, "d": SymbolDark,
+	}
+
+	face := Face{Symbols: make(map[string]int)}
+	var label []string
+	for _, code := range codes {
+		face.Symbols[names[code]]++
+		label = append(label, GenesysIcons[names[code]])
+	}
+
+	face.Label = strings.Join(label, "")
+	if face.Label == "" {
+		face.Label = "▫️"
+	}
+	return face
+}
+
+// GenesysDice holds the narrative dice of the Genesys system (and Star Wars RPG), keyed by name.
+var GenesysDice = map[string]*FaceDie{
+	"boost": {Name: "boost", Faces: []Face{
+		genesysFace(), genesysFace(), genesysFace("s"), genesysFace("s", "a"), genesysFace("a", "a"), genesysFace("a"),
+	}},
+	"setback": {Name: "setback", Faces: []Face{
+		genesysFace(), genesysFace(), genesysFace("f"), genesysFace("f"), genesysFace("t"), genesysFace("t"),
+	}},
+	"ability": {Name: "ability", Faces: []Face{
+		genesysFace(), genesysFace("s"), genesysFace("s"), genesysFace("s", "s"),
+		genesysFace("a"), genesysFace("a"), genesysFace("s", "a"), genesysFace("a", "a"),
+	}},
+	"difficulty": {Name: "difficulty", Faces: []Face{
+		genesysFace(), genesysFace("f"), genesysFace("f", "f"), genesysFace("t"),
+		genesysFace("t"), genesysFace("t"), genesysFace("t", "t"), genesysFace("f", "t"),
+	}},
+	"proficiency": {Name: "proficiency", Faces: []Face{
+		genesysFace(), genesysFace("s"), genesysFace("s"), genesysFace("s", "s"), genesysFace("s", "s"), genesysFace("a"),
+		genesysFace("s", "a"), genesysFace("s", "a"), genesysFace("s", "a"), genesysFace("a", "a"), genesysFace("a", "a"), genesysFace("tr"),
+	}},
+	"challenge": {Name: "challenge", Faces: []Face{
+		genesysFace(), genesysFace("f"), genesysFace("f"), genesysFace("f", "f"), genesysFace("f", "f"), genesysFace("t"),
+		genesysFace("t"), genesysFace("f", "t"), genesysFace("f", "t"), genesysFace("t", "t"), genesysFace("t", "t"), genesysFace("de"),
+	}},
+	"force": {Name: "force", Faces: []Face{
+		genesysFace("d"), genesysFace("d"), genesysFace("d"), genesysFace("d"), genesysFace("d"), genesysFace("d"),
+		genesysFace("d", "d"), genesysFace("l"), genesysFace("l"), genesysFace("l", "l"), genesysFace("l", "l"), genesysFace("l", "l"),
+	}},
+}
+
+// genesysCodes maps the short dice codes to their names.
+var genesysCodes = map[string]string{
+	"b": "boost", "s": "setback", "a": "ability", "d": "difficulty",
+	"p": "proficiency", "c": "challenge", "f": "force",
+}
+
+var genesysTokenPattern = regexp.MustCompile(`^(\d*)([a-z]+)$`)
+
+// ParseGenesysPool reads a pool such as "2a 1p 2d 1c" or "2ability proficiency 2difficulty".
+func ParseGenesysPool(input string) ([]*FaceDie, error) {
+	var pool []*FaceDie
+	total := 0
+
+	for _, token := range strings.Fields(strings.ToLower(input)) {
+		match := genesysTokenPattern.FindStringSubmatch(token)
+		if match == nil {
+			return nil, fmt.Errorf("invalid dice %q, use e.g. `2a 1p 2d 1c`", token)
+		}
+
+		count := 1
+		if match[1] != "" {
+			var err error
+			if count, err = strconv.Atoi(match[1]); err != nil {
+				return nil, fmt.Errorf("up to %d narrative dice can be rolled at once", MaxTerms*2)
+			}
+		}
+
+		// check the size before building the pool, so a huge count can't allocate billions of dice
+		total += count
+		if total > MaxTerms*2 {
+			return nil, fmt.Errorf("up to %d narrative dice can be rolled at once", MaxTerms*2)
+		}
+
+		name := match[2]
+		if full, ok := genesysCodes[name]; ok {
+			name = full
+		}
+
+		die, ok := GenesysDice[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown die %q", match[2])
+		}
+
+		for i := 0; i < count; i++ {
+			pool = append(pool, die)
+		}
+	}
+
+	if len(pool) == 0 {
+		return nil, fmt.Errorf("no dice given, use e.g. `2a 1p 2d 1c`")
+	}
+
+	return pool, nil
+}
+
+// GenesysResult is the net result of a narrative dice roll after cancellation.
+type GenesysResult struct {
+	Rolls []FaceRoll
+	// Success is the net success, negative for net failure. Triumphs count as successes and despairs as failures.
+	Success int
+	// Advantage is the net advantage, negative for net threat.
+	Advantage int
+	Triumph   int
+	Despair   int
+	Light     int
+	Dark      int
+}
+
+// RollGenesys rolls the pool and cancels successes against failures and advantages against threats.
+func RollGenesys(src Source, pool []*FaceDie) *GenesysResult {
+	result := &GenesysResult{Rolls: RollFaces(src, pool)}
+
+	for _, count := range CountSymbols(result.Rolls) {
+		switch count.Symbol {
+		case SymbolSuccess:
+			result.Success += count.Count
+		case SymbolFailure:
+			result.Success -= count.Count
+		case SymbolAdvantage:
+			result.Advantage += count.Count
+		case SymbolThreat:
+			result.Advantage -= count.Count
+		case SymbolTriumph:
+			result.Triumph += count.Count
+			result.Success += count.Count
+		case SymbolDespair:
+			result.Despair += count.Count
+			result.Success -= count.Count
+		case SymbolLight:
+			result.Light += count.Count
+		case SymbolDark:
+			result.Dark += count.Count
+		}
+	}
+
+	return result
+}
+
+// Succeeded reports whether the check passed with at least one net success.
+func (r *GenesysResult) Succeeded() bool {
+	return r.Success > 0
+}
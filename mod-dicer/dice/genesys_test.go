@@ -0,0 +1,38 @@
+package dice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGenesysPool(t *testing.T) {
+	pool, err := ParseGenesysPool("2a 1p difficulty 2c")
+	assert.NoError(t, err)
+	assert.Len(t, pool, 6)
+	assert.Equal(t, "ability", pool[0].Name)
+	assert.Equal(t, "proficiency", pool[2].Name)
+	assert.Equal(t, "difficulty", pool[3].Name)
+
+	for _, input := range []string{"", "2x", "a-b", "0a"} {
+		_, err := ParseGenesysPool(input)
+		assert.Error(t, err, input)
+	}
+
+	for _, input := range []string{"21a", "10a 10p 1d", "2000000000a", "99999999999999999999a"} {
+		_, err := ParseGenesysPool(input)
+		assert.EqualError(t, err, "up to 20 narrative dice can be rolled at once", input)
+	}
+}
+
+func TestRollGenesys(t *testing.T) {
+	// proficiency triumph, ability success+advantage, challenge failure+threat
+	src := &fixedSource{values: []int{12, 7, 8}}
+	pool := []*FaceDie{GenesysDice["proficiency"], GenesysDice["ability"], GenesysDice["challenge"]}
+
+	result := RollGenesys(src, pool)
+	assert.Equal(t, 1, result.Success)
+	assert.Equal(t, 0, result.Advantage)
+	assert.Equal(t, 1, result.Triumph)
+	assert.True(t, result.Succeeded())
+}
@@ -0,0 +1,143 @@
+package dice
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxIronScore caps action scores and progress scores.
+	MaxIronScore = 10
+	// IronTrackTicks is the number of ticks in a full progress track of ten boxes.
+	IronTrackTicks = 40
+	// IronBoxTicks is the number of ticks in a single progress box.
+	IronBoxTicks = 4
+)
+
+// IronRanks maps the challenge ranks to the ticks marked per progress.
+var IronRanks = map[string]int{
+	"troublesome": 12,
+	"dangerous":   8,
+	"formidable":  4,
+	"extreme":     2,
+	"epic":        1,
+}
+
+// IronOutcome is the result of an Ironsworn action or progress roll.
+type IronOutcome int
+
+const (
+	IronMiss IronOutcome = iota
+	IronWeakHit
+	IronStrongHit
+)
+
+// String returns the human readable name of the outcome.
+func (o IronOutcome) String() string {
+	switch o {
+	case IronStrongHit:
+		return "Strong hit"
+	case IronWeakHit:
+		return "Weak hit"
+	}
+	return "Miss"
+}
+
+// IronRoll is an action or progress roll against two challenge dice.
+type IronRoll struct {
+	// Action is the action die, 0 for progress rolls.
+	Action    int
+	Score     int
+	Challenge [2]int
+	Outcome   IronOutcome
+	Match     bool
+}
+
+// RollIronAction rolls the action die plus stat and adds against two challenge dice. The action score is capped at 10.
+func RollIronAction(src Source, stat, adds int) (*IronRoll, error) {
+	if stat < 0 || stat > 5 {
+		return nil, fmt.Errorf("stat should be between 0 and 5")
+	}
+	if adds < 0 || adds > MaxIronScore {
+		return nil, fmt.Errorf("adds should be between 0 and %d", MaxIronScore)
+	}
+
+	action := Roll(src, 6)
+	score := action + stat + adds
+	if score > MaxIronScore {
+		score = MaxIronScore
+	}
+
+	roll := resolveIron(src, score)
+	roll.Action = action
+	return roll, nil
+}
+
+// RollIronProgress rolls a progress score (filled boxes) against two challenge dice.
+func RollIronProgress(src Source, score int) (*IronRoll, error) {
+	if score < 0 || score > MaxIronScore {
+		return nil, fmt.Errorf("progress score should be between 0 and %d", MaxIronScore)
+	}
+	return resolveIron(src, score), nil
+}
+
+// resolveIron rolls the challenge dice and compares them to the score. Ties go to the challenge dice.
+func resolveIron(src Source, score int) *IronRoll {
+	roll := &IronRoll{Score: score, Challenge: [2]int{Roll(src, 10), Roll(src, 10)}}
+
+	beaten := 0
+	for _, challenge := range roll.Challenge {
+		if score > challenge {
+			beaten++
+		}
+	}
+
+	roll.Outcome = IronOutcome(beaten)
+	roll.Match = roll.Challenge[0] == roll.Challenge[1]
+	return roll
+}
+
+// IronTicksPerMark returns the ticks marked per progress for a rank.
+func IronTicksPerMark(rank string) (int, error) {
+	ticks, ok := IronRanks[rank]
+	if !ok {
+		return 0, fmt.Errorf("unknown rank %q, use troublesome, dangerous, formidable, extreme or epic", rank)
+	}
+	return ticks, nil
+}
+
+// IronProgressBar renders a progress track as ten boxes, e.g. "■■■◑□□□□□□".
+func IronProgressBar(ticks int) string {
+	partial := []string{"□", "◔", "◑", "◕"}
+
+	var sb strings.Builder
+	for box := 0; box < IronTrackTicks/IronBoxTicks; box++ {
+		filled := ticks - box*IronBoxTicks
+		switch {
+		case filled >= IronBoxTicks:
+			sb.WriteString("■")
+		case filled > 0:
+			sb.WriteString(partial[filled])
+		default:
+			sb.WriteString(partial[0])
+		}
+	}
+	return sb.String()
+}
+
+// IronOracle rolls on an oracle table with 1d100.
+func IronOracle(src Source, table []string) (int, string) {
+	roll := Roll(src, 100)
+	return roll, table[(roll-1)*len(table)/100]
+}
+
+// IronPayThePrice rolls on the Pay the Price table.
+func IronPayThePrice(src Source) (int, string) {
+	roll := Roll(src, 100)
+	for _, entry := range ironPayThePrice {
+		if roll <= entry.Max {
+			return roll, entry.Text
+		}
+	}
+	return roll, ironPayThePrice[len(ironPayThePrice)-1].Text
+}
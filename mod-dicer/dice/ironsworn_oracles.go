@@ -0,0 +1,57 @@
+package dice
+
+// Oracle tables from Ironsworn by Shawn Tomkin, licensed under CC BY 4.0 (https://www.ironswornrpg.com).
+
+// IronActionOracle is the Ironsworn Action oracle, rolled with 1d100.
+var IronActionOracle = []string{
+	"Scheme", "Clash", "Weaken", "Initiate", "Create", "Swear", "Avenge", "Guard", "Defeat", "Control",
+	"Break", "Risk", "Surrender", "Inspect", "Raid", "Evade", "Assault", "Deflect", "Threaten", "Attack",
+	"Leave", "Preserve", "Manipulate", "Remove", "Eliminate", "Withdraw", "Abandon", "Investigate", "Hold", "Focus",
+	"Uncover", "Breach", "Aid", "Uphold", "Falter", "Suppress", "Hunt", "Share", "Destroy", "Avoid",
+	"Reject", "Demand", "Explore", "Bolster", "Seize", "Mourn", "Reveal", "Gather", "Defy", "Transform",
+	"Persevere", "Serve", "Begin", "Move", "Coordinate", "Resist", "Await", "Impress", "Take", "Oppose",
+	"Capture", "Overwhelm", "Challenge", "Acquire", "Protect", "Finish", "Strengthen", "Restore", "Advance", "Command",
+	"Refuse", "Find", "Deliver", "Hide", "Fortify", "Betray", "Secure", "Arrive", "Affect", "Change",
+	"Defend", "Debate", "Support", "Follow", "Construct", "Locate", "Endure", "Release", "Lose", "Reduce",
+	"Escalate", "Distract", "Journey", "Escort", "Learn", "Communicate", "Depart", "Search", "Charge", "Summon",
+}
+
+// IronThemeOracle is the Ironsworn Theme oracle, rolled with 1d100.
+var IronThemeOracle = []string{
+	"Risk", "Ability", "Price", "Ally", "Battle", "Safety", "Survival", "Weapon", "Wound", "Shelter",
+	"Leader", "Fear", "Time", "Duty", "Secret", "Innocence", "Renown", "Direction", "Death", "Honor",
+	"Labor", "Solution", "Tool", "Balance", "Love", "Barrier", "Creation", "Decay", "Trade", "Bond",
+	"Hope", "Superstition", "Peace", "Deception", "History", "World", "Vow", "Protection", "Nature", "Opinion",
+	"Burden", "Vengeance", "Opportunity", "Faction", "Danger", "Corruption", "Freedom", "Debt", "Hate", "Possession",
+	"Stranger", "Passage", "Land", "Creature", "Disease", "Advantage", "Blood", "Language", "Rumor", "Weakness",
+	"Greed", "Family", "Resource", "Structure", "Dream", "Community", "War", "Portent", "Prize", "Destiny",
+	"Momentum", "Power", "Memory", "Ruin", "Mysticism", "Rival", "Problem", "Idea", "Revenge", "Health",
+	"Fellowship", "Enemy", "Religion", "Spirit", "Fame", "Desolation", "Strength", "Knowledge", "Truth", "Quest",
+	"Pride", "Loss", "Law", "Path", "Warning", "Relationship", "Wealth", "Home", "Strategy", "Supply",
+}
+
+// ironRangeEntry is a row of a d100 table covering rolls up to Max.
+type ironRangeEntry struct {
+	Max  int
+	Text string
+}
+
+// ironPayThePrice is the Ironsworn Pay the Price table.
+var ironPayThePrice = []ironRangeEntry{
+	{2, "Roll again and apply that result but make it worse. If you roll this result yet again, think of something dreadful that changes the course of your quest and make it happen."},
+	{5, "A person or community you trusted loses faith in you, or acts against you."},
+	{9, "A person or community you care about is exposed to danger."},
+	{16, "You are separated from something or someone."},
+	{23, "Your action has an unintended effect."},
+	{32, "Something of value is lost or destroyed."},
+	{41, "The current situation worsens."},
+	{50, "A new danger or foe is revealed."},
+	{59, "It causes a delay or puts you at a disadvantage."},
+	{68, "It is harmful."},
+	{76, "It is stressful."},
+	{85, "A surprising development complicates your quest."},
+	{90, "It wastes resources."},
+	{94, "It forces you to act against your best intentions."},
+	{98, "A friend, companion, or ally is put in harm's way (or you are, if alone)."},
+	{100, "Roll twice more on this table. Both results occur. If they are the same result, make it worse."},
+}
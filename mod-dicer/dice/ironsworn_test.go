@@ -0,0 +1,52 @@
+package dice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollIronAction(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []int
+		stat    int
+		adds    int
+		score   int
+		outcome IronOutcome
+		match   bool
+	}{
+		{"StrongHit", []int{4, 3, 5}, 2, 0, 6, IronStrongHit, false},
+		{"WeakHitOnTie", []int{4, 6, 2}, 2, 0, 6, IronWeakHit, false},
+		{"MissWithMatch", []int{1, 9, 9}, 1, 0, 2, IronMiss, true},
+		{"ScoreCappedAtTen", []int{6, 10, 9}, 5, 3, 10, IronWeakHit, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roll, err := RollIronAction(&fixedSource{values: tt.values}, tt.stat, tt.adds)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.score, roll.Score)
+			assert.Equal(t, tt.outcome, roll.Outcome)
+			assert.Equal(t, tt.match, roll.Match)
+		})
+	}
+
+	_, err := RollIronAction(Crypto, 6, 0)
+	assert.Error(t, err)
+}
+
+func TestIronProgressBar(t *testing.T) {
+	assert.Equal(t, "□□□□□□□□□□", IronProgressBar(0))
+	assert.Equal(t, "■■■◑□□□□□□", IronProgressBar(14))
+	assert.Equal(t, "■■■■■■■■■■", IronProgressBar(IronTrackTicks))
+}
+
+func TestIronOracles(t *testing.T) {
+	assert.Len(t, IronActionOracle, 100)
+	assert.Len(t, IronThemeOracle, 100)
+
+	roll, text := IronPayThePrice(&fixedSource{values: []int{100}})
+	assert.Equal(t, 100, roll)
+	assert.Contains(t, text, "Roll twice more")
+}
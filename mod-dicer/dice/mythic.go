@@ -0,0 +1,234 @@
+package dice
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	MinChaos     = 1
+	MaxChaos     = 9
+	DefaultChaos = 5
+)
+
+// Odds is how likely the answer to a fate question is yes.
+type Odds int
+
+const (
+	Impossible Odds = iota
+	NoWay
+	VeryUnlikely
+	Unlikely
+	FiftyFifty
+	SomewhatLikely
+	Likely
+	VeryLikely
+	NearSureThing
+	SureThing
+	HasToBe
+)
+
+// OddsNames are the names of the odds in order, as used on the fate chart.
+var OddsNames = []string{
+	"impossible", "no way", "very unlikely", "unlikely", "50/50", "somewhat likely",
+	"likely", "very likely", "near sure thing", "a sure thing", "has to be",
+}
+
+// oddsAliases are the other ways to write the odds.
+var oddsAliases = map[string]Odds{
+	"50-50":      FiftyFifty,
+	"even":       FiftyFifty,
+	"near sure":  NearSureThing,
+	"sure":       SureThing,
+	"sure thing": SureThing,
+}
+
+// String returns the name of the odds.
+func (o Odds) String() string {
+	return OddsNames[o]
+}
+
+// fateChart holds the chance of a yes for each odds (rows) and chaos factor 1 to 9 (columns).
+var fateChart = [][MaxChaos]int{
+	Impossible:     {-20, 0, 0, 5, 5, 10, 15, 25, 50},
+	NoWay:          {0, 5, 5, 10, 15, 25, 35, 50, 75},
+	VeryUnlikely:   {5, 5, 10, 15, 25, 45, 50, 65, 85},
+	Unlikely:       {5, 10, 15, 20, 35, 50, 55, 75, 90},
+	FiftyFifty:     {10, 15, 25, 35, 50, 65, 75, 85, 90},
+	SomewhatLikely: {20, 25, 45, 50, 65, 80, 85, 90, 95},
+	Likely:         {25, 35, 50, 55, 75, 85, 90, 95, 95},
+	VeryLikely:     {45, 50, 65, 75, 85, 90, 95, 95, 100},
+	NearSureThing:  {50, 55, 75, 80, 90, 95, 95, 100, 105},
+	SureThing:      {55, 65, 80, 85, 90, 95, 95, 110, 115},
+	HasToBe:        {80, 85, 90, 95, 95, 100, 100, 130, 145},
+}
+
+// ParseOdds reads the odds at the start of the text, e.g. "very likely is the door locked?",
+// and returns them with the rest of the text. It reports false when the text doesn't start with odds.
+func ParseOdds(text string) (Odds, string, bool) {
+	words := strings.Fields(strings.ToLower(text))
+
+	// try the longest odds names first, e.g. "very likely" before "likely"
+	for n := 3; n >= 1; n-- {
+		if len(words) < n {
+			continue
+		}
+		prefix := strings.Join(words[:n], " ")
+		rest := strings.Join(strings.Fields(text)[n:], " ")
+
+		for i, name := range OddsNames {
+			if prefix == name {
+				return Odds(i), rest, true
+			}
+		}
+		if odds, ok := oddsAliases[prefix]; ok {
+			return odds, rest, true
+		}
+	}
+
+	return FiftyFifty, text, false
+}
+
+// Answer is the answer of the fate chart.
+type Answer int
+
+const (
+	ExceptionalNo Answer = iota
+	No
+	Yes
+	ExceptionalYes
+)
+
+// String returns the human readable answer.
+func (a Answer) String() string {
+	switch a {
+	case ExceptionalYes:
+		return "Exceptional yes"
+	case Yes:
+		return "Yes"
+	case No:
+		return "No"
+	}
+	return "Exceptional no"
+}
+
+// FateThresholds returns the highest rolls giving an exceptional yes and a yes, and the lowest roll
+// giving an exceptional no. Thresholds out of the 1 to 100 range can't be rolled.
+func FateThresholds(odds Odds, chaos int) (exceptionalYes, yes, exceptionalNo int) {
+	yes = fateChart[odds][chaos-1]
+	exceptionalYes = yes / 5
+	exceptionalNo = 100 - (100-yes)/5 + 1
+	return exceptionalYes, yes, exceptionalNo
+}
+
+// FateQuestion is a yes/no question asked to the fate chart.
+type FateQuestion struct {
+	Odds   Odds
+	Chaos  int
+	Roll   int
+	Answer Answer
+	// Event is the random event triggered by the roll, nil when there is none.
+	Event *RandomEvent
+}
+
+// AskFateChart rolls 1d100 on the fate chart. Doubles up to the chaos factor, e.g. 33 at chaos 5, trigger a random event.
+func AskFateChart(src Source, odds Odds, chaos int) (*FateQuestion, error) {
+	if odds < Impossible || odds > HasToBe {
+		return nil, fmt.Errorf("unknown odds")
+	}
+	if chaos < MinChaos || chaos > MaxChaos {
+		return nil, fmt.Errorf("chaos factor should be between %d and %d", MinChaos, MaxChaos)
+	}
+
+	q := &FateQuestion{Odds: odds, Chaos: chaos, Roll: Roll(src, 100)}
+
+	exceptionalYes, yes, exceptionalNo := FateThresholds(odds, chaos)
+	switch {
+	case q.Roll <= exceptionalYes:
+		q.Answer = ExceptionalYes
+	case q.Roll <= yes:
+		q.Answer = Yes
+	case q.Roll >= exceptionalNo:
+		q.Answer = ExceptionalNo
+	default:
+		q.Answer = No
+	}
+
+	if q.Roll < 100 && q.Roll%11 == 0 && q.Roll/11 <= chaos {
+		q.Event = RollRandomEvent(src)
+	}
+
+	return q, nil
+}
+
+// RandomEvent is an unexpected turn of the story: what it is about and what it means.
+type RandomEvent struct {
+	FocusRoll int
+	Focus     string
+	Action    string
+	Subject   string
+}
+
+// eventFocus is the event focus table, rolled with 1d100.
+var eventFocus = []struct {
+	Max  int
+	Text string
+}{
+	{7, "Remote event"},
+	{28, "NPC action"},
+	{35, "Introduce a new NPC"},
+	{45, "Move toward a thread"},
+	{52, "Move away from a thread"},
+	{55, "Close a thread"},
+	{67, "PC negative"},
+	{75, "PC positive"},
+	{83, "Ambiguous event"},
+	{92, "NPC negative"},
+	{100, "NPC positive"},
+}
+
+// RollRandomEvent rolls the focus of a random event and its meaning.
+func RollRandomEvent(src Source) *RandomEvent {
+	event := &RandomEvent{FocusRoll: Roll(src, 100)}
+	for _, entry := range eventFocus {
+		if event.FocusRoll <= entry.Max {
+			event.Focus = entry.Text
+			break
+		}
+	}
+	event.Action, event.Subject = RollMeaning(src)
+	return event
+}
+
+// RollMeaning rolls a word on the meaning action and subject tables, e.g. "Betray" and "Plans".
+func RollMeaning(src Source) (string, string) {
+	return meaningActions[Roll(src, 100)-1], meaningSubjects[Roll(src, 100)-1]
+}
+
+// meaningActions is the meaning action table, rolled with 1d100.
+var meaningActions = []string{
+	"Abandon", "Accept", "Accuse", "Aid", "Ambush", "Arrive", "Attack", "Bargain", "Befriend", "Betray",
+	"Block", "Break", "Bribe", "Build", "Capture", "Celebrate", "Challenge", "Change", "Chase", "Claim",
+	"Conceal", "Confess", "Confront", "Corrupt", "Create", "Curse", "Deceive", "Decline", "Defend", "Delay",
+	"Deliver", "Demand", "Depart", "Destroy", "Discover", "Disrupt", "Divide", "Doubt", "Embrace", "Escape",
+	"Expose", "Fail", "Flee", "Follow", "Forgive", "Gather", "Guide", "Harm", "Heal", "Hide",
+	"Hunt", "Imitate", "Imprison", "Inform", "Inherit", "Inspect", "Invade", "Judge", "Lead", "Lie",
+	"Lose", "Mourn", "Negotiate", "Neglect", "Obey", "Observe", "Oppose", "Overthrow", "Persuade", "Plot",
+	"Praise", "Protect", "Provoke", "Pursue", "Question", "Rebel", "Recover", "Refuse", "Release", "Rescue",
+	"Restore", "Reveal", "Reward", "Rob", "Sacrifice", "Search", "Seize", "Separate", "Spy", "Steal",
+	"Summon", "Surrender", "Suspect", "Tempt", "Threaten", "Trade", "Transform", "Trap", "Unite", "Warn",
+}
+
+// meaningSubjects is the meaning subject table, rolled with 1d100.
+var meaningSubjects = []string{
+	"Alliance", "Ambition", "Ancestor", "Animal", "Army", "Art", "Authority", "Beast", "Belief", "Blood",
+	"Bond", "Border", "Burden", "Ceremony", "Child", "Coin", "Community", "Contract", "Crime", "Crown",
+	"Curse", "Danger", "Debt", "Desire", "Disease", "Dream", "Duty", "Enemy", "Envy", "Exile",
+	"Faith", "Family", "Fear", "Feast", "Fire", "Food", "Fortune", "Friend", "Gift", "Grave",
+	"Guild", "Guilt", "Home", "Honor", "Hope", "Hunger", "Illusion", "Inheritance", "Injury", "Journey",
+	"Justice", "Key", "Knowledge", "Land", "Law", "Leader", "Letter", "Lie", "Love", "Loyalty",
+	"Machine", "Magic", "Map", "Memory", "Messenger", "Monster", "Nature", "News", "Oath", "Outsider",
+	"Pain", "Path", "Peace", "Plague", "Plans", "Power", "Prize", "Prophecy", "Refuge", "Relic",
+	"Revenge", "Rival", "Ruin", "Rumor", "Secret", "Servant", "Shelter", "Ship", "Spirit", "Storm",
+	"Stranger", "Tomb", "Trap", "Treasure", "Truth", "Vehicle", "War", "Water", "Weapon", "Weather",
+}
@@ -0,0 +1,82 @@
+package dice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFateThresholds(t *testing.T) {
+	tests := []struct {
+		odds                               Odds
+		chaos                              int
+		exceptionalYes, yes, exceptionalNo int
+	}{
+		{FiftyFifty, 5, 10, 50, 91},
+		{Likely, 5, 15, 75, 96},
+		{Impossible, 1, -4, -20, 77},
+		{HasToBe, 9, 29, 145, 110},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.odds.String(), func(t *testing.T) {
+			exceptionalYes, yes, exceptionalNo := FateThresholds(tt.odds, tt.chaos)
+			assert.Equal(t, tt.exceptionalYes, exceptionalYes)
+			assert.Equal(t, tt.yes, yes)
+			assert.Equal(t, tt.exceptionalNo, exceptionalNo)
+		})
+	}
+}
+
+func TestAskFateChart(t *testing.T) {
+	tests := []struct {
+		name   string
+		roll   int
+		answer Answer
+		event  bool
+	}{
+		{"ExceptionalYes", 10, ExceptionalYes, false},
+		{"YesWithEvent", 44, Yes, true},
+		{"NoDoublesAboveChaos", 66, No, false},
+		{"ExceptionalNo", 95, ExceptionalNo, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := AskFateChart(&fixedSource{values: []int{tt.roll, 50}}, FiftyFifty, 5)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.answer, q.Answer)
+			assert.Equal(t, tt.event, q.Event != nil)
+		})
+	}
+
+	_, err := AskFateChart(Crypto, Likely, 10)
+	assert.Error(t, err)
+}
+
+func TestRollRandomEvent(t *testing.T) {
+	event := RollRandomEvent(&fixedSource{values: []int{30, 10, 75}})
+	assert.Equal(t, "Introduce a new NPC", event.Focus)
+	assert.Equal(t, "Betray", event.Action)
+	assert.Equal(t, "Plans", event.Subject)
+}
+
+func TestParseOdds(t *testing.T) {
+	odds, rest, ok := ParseOdds("Very Likely is the door locked?")
+	assert.True(t, ok)
+	assert.Equal(t, VeryLikely, odds)
+	assert.Equal(t, "is the door locked?", rest)
+
+	odds, _, ok = ParseOdds("likely")
+	assert.True(t, ok)
+	assert.Equal(t, Likely, odds)
+
+	odds, _, ok = ParseOdds("near sure")
+	assert.True(t, ok)
+	assert.Equal(t, NearSureThing, odds)
+
+	odds, rest, ok = ParseOdds("is it raining?")
+	assert.False(t, ok)
+	assert.Equal(t, FiftyFifty, odds)
+	assert.Equal(t, "is it raining?", rest)
+}
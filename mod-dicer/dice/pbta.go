@@ -0,0 +1,88 @@
+package dice
+
+import (
+	"fmt"
+)
+
+// Band is the outcome band of a Powered by the Apocalypse move.
+type Band int
+
+const (
+	Miss Band = iota
+	WeakHit
+	StrongHit
+)
+
+// BandLabels names the outcome bands of a move.
+type BandLabels struct {
+	StrongHit string
+	WeakHit   string
+	Miss      string
+}
+
+// DefaultBandLabels are the labels used when a guild has not configured its own.
+var DefaultBandLabels = BandLabels{
+	StrongHit: "Strong hit",
+	WeakHit:   "Weak hit",
+	Miss:      "Miss",
+}
+
+// Label returns the label of the band.
+func (l BandLabels) Label(band Band) string {
+	switch band {
+	case StrongHit:
+		return l.StrongHit
+	case WeakHit:
+		return l.WeakHit
+	}
+	return l.Miss
+}
+
+// MoveBand returns the band of a move total: 10+ strong hit, 7-9 weak hit, 6- miss.
+func MoveBand(total int) Band {
+	switch {
+	case total >= 10:
+		return StrongHit
+	case total >= 7:
+		return WeakHit
+	}
+	return Miss
+}
+
+// MoveExpression builds the expression of a move: 2d6 plus the modifier terms,
+// or 3d6 keeping the two highest (advantage, positive) or lowest (disadvantage, negative) dice.
+// A leading 2d6 in the modifiers is accepted and replaced, any other dice are rejected.
+func MoveExpression(modifiers string, advantage int) (*Expression, error) {
+	base := Term{Sign: 1, Count: 2, Sides: 6}
+	switch {
+	case advantage > 0:
+		base = Term{Sign: 1, Count: 3, Sides: 6, Keep: 2}
+	case advantage < 0:
+		base = Term{Sign: 1, Count: 3, Sides: 6, Keep: 2, KeepLowest: true}
+	}
+
+	expr := &Expression{Terms: []Term{base}}
+	if modifiers == "" {
+		return expr, nil
+	}
+
+	parsed, err := Parse(modifiers)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Check != nil {
+		return nil, fmt.Errorf("moves are graded by their own bands, drop the difficulty")
+	}
+
+	for i, term := range parsed.Terms {
+		if i == 0 && term.Sign > 0 && term.String() == "2d6" {
+			continue
+		}
+		if term.IsDice() {
+			return nil, fmt.Errorf("moves roll 2d6 plus modifiers, e.g. `2d6+2` or `+2`")
+		}
+		expr.Terms = append(expr.Terms, term)
+	}
+
+	return expr, nil
+}
@@ -0,0 +1,61 @@
+package dice
+
+import (
+	"crypto/rand"
+	"math/big"
+	mathrand "math/rand"
+	"sync"
+)
+
+// Source provides random numbers for rolling dice.
+type Source interface {
+	// Intn returns a random number in the range [0, n).
+	Intn(n int) int
+}
+
+// Crypto is the default source backed by crypto/rand.
+var Crypto Source = cryptoSource{}
+
+type cryptoSource struct{}
+
+// Intn returns a cryptographically secure random number in the range [0, n).
+func (cryptoSource) Intn(n int) int {
+	if n <= 0 {
+		return 0
+	}
+
+	randomNum, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		// crypto/rand only fails when the system entropy source is unavailable
+		panic(err)
+	}
+
+	return int(randomNum.Int64())
+}
+
+type seededSource struct {
+	mu  sync.Mutex
+	rnd *mathrand.Rand
+}
+
+// NewSeededSource creates a deterministic source, so the same seed always gives the same rolls.
+func NewSeededSource(seed int64) Source {
+	return &seededSource{rnd: mathrand.New(mathrand.NewSource(seed))}
+}
+
+// Intn returns a pseudo-random number in the range [0, n).
+func (s *seededSource) Intn(n int) int {
+	if n <= 0 {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rnd.Intn(n)
+}
+
+// Roll rolls a single die with the given number of sides and returns a value between 1 and sides (inclusive).
+func Roll(src Source, sides int) int {
+	return src.Intn(sides) + 1
+}
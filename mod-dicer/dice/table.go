@@ -0,0 +1,301 @@
+package dice
+
+import (
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxTableRows is the number of rows a random table can have.
+	MaxTableRows = 1000
+	// MaxTableName is the length of a random table name.
+	MaxTableName = 32
+	// MaxTableDepth is how deep table references can be nested, it also stops reference loops.
+	MaxTableDepth = 5
+	// MaxTableRolls is the number of tables rolled on for a single result, references included.
+	MaxTableRolls = 50
+)
+
+var (
+	tableNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+	// tableRefPattern matches a reference to another table inside an entry, e.g. "[[table:treasure]]".
+	tableRefPattern = regexp.MustCompile(`(?i)\[\[\s*table\s*:\s*([a-z0-9_-]+)\s*\]\]`)
+	// entryDicePattern matches the dice rolled inside an entry, e.g. "2d4" or "1d6+1".
+	entryDicePattern = regexp.MustCompile(`(?i)\b\d*d\d+(?:[+-]\d+)?\b`)
+	// tableSeparatorPattern matches a cell of the Markdown row under the header, e.g. "---" or ":-:".
+	tableSeparatorPattern = regexp.MustCompile(`^:?-+:?$`)
+)
+
+// TableRow is a range of die results and the entry they give.
+type TableRow struct {
+	Min  int
+	Max  int
+	Text string
+}
+
+// Table is a random table rolled on with a single die covering all of its rows.
+type Table struct {
+	Name string
+	Rows []TableRow
+}
+
+// Die returns the number of sides of the die rolled on the table.
+func (t *Table) Die() int {
+	return t.Rows[len(t.Rows)-1].Max
+}
+
+// Roll rolls the table die and returns the result with its row.
+func (t *Table) Roll(src Source) (int, TableRow) {
+	roll := Roll(src, t.Die())
+	for _, row := range t.Rows {
+		if roll <= row.Max {
+			return roll, row
+		}
+	}
+	return roll, t.Rows[len(t.Rows)-1]
+}
+
+// String writes the table back as "1-3 | Goblins" rows that ParseTable accepts.
+func (t *Table) String() string {
+	lines := make([]string, len(t.Rows))
+	for i, row := range t.Rows {
+		rng := strconv.Itoa(row.Min)
+		if row.Max != row.Min {
+			rng += "-" + strconv.Itoa(row.Max)
+		}
+		lines[i] = rng + " | " + row.Text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ValidateTableName checks that a table name starts with a letter and uses letters, digits, "-" or "_".
+func ValidateTableName(name string) error {
+	if len(name) > MaxTableName || !tableNamePattern.MatchString(name) {
+		return fmt.Errorf("table names start with a letter and use up to %d letters, digits, `-` or `_`", MaxTableName)
+	}
+	return nil
+}
+
+// ParseTable reads a random table from CSV ("1-3,Goblins") or Markdown ("| 1-3 | Goblins |") rows.
+// A header row before the first range is skipped, "00" reads as 100, and the ranges must cover 1 to the die size without gaps.
+func ParseTable(name, content string) (*Table, error) {
+	name = strings.ToLower(name)
+	if err := ValidateTableName(name); err != nil {
+		return nil, err
+	}
+
+	table := &Table{Name: name}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		cells, err := splitTableLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if isSeparatorRow(cells) {
+			continue
+		}
+
+		first, last, err := parseTableRange(cells[0])
+		if err != nil {
+			if len(table.Rows) == 0 {
+				// header row, e.g. "d6 | Encounter"
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+
+		text := joinTableCells(cells[1:])
+		if text == "" {
+			return nil, fmt.Errorf("line %d: the entry is empty", i+1)
+		}
+
+		expected := 1
+		if len(table.Rows) > 0 {
+			expected = table.Rows[len(table.Rows)-1].Max + 1
+		}
+		if first != expected {
+			return nil, fmt.Errorf("line %d: the range should start at %d", i+1, expected)
+		}
+
+		table.Rows = append(table.Rows, TableRow{Min: first, Max: last, Text: text})
+		if len(table.Rows) > MaxTableRows {
+			return nil, fmt.Errorf("tables can have up to %d rows", MaxTableRows)
+		}
+	}
+
+	if len(table.Rows) == 0 {
+		return nil, fmt.Errorf("no `1-3 | entry` rows found")
+	}
+
+	return table, nil
+}
+
+// splitTableLine splits a Markdown, "|" separated or CSV line into its cells.
+func splitTableLine(line string) ([]string, error) {
+	var cells []string
+	if strings.Contains(line, "|") {
+		cells = strings.Split(strings.Trim(line, "|"), "|")
+	} else {
+		reader := csv.NewReader(strings.NewReader(line))
+		reader.LazyQuotes = true
+		reader.TrimLeadingSpace = true
+		record, err := reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		// unquoted commas inside a CSV entry split it into more cells
+		cells = []string{record[0], strings.Join(record[1:], ", ")}
+	}
+
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	if len(cells) < 2 {
+		return nil, fmt.Errorf("expected a range and an entry")
+	}
+	return cells, nil
+}
+
+// isSeparatorRow reports whether the cells are the Markdown row under the header.
+func isSeparatorRow(cells []string) bool {
+	for _, cell := range cells {
+		if !tableSeparatorPattern.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// joinTableCells joins the non-empty entry cells of a row.
+func joinTableCells(cells []string) string {
+	var parts []string
+	for _, cell := range cells {
+		if cell != "" {
+			parts = append(parts, cell)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// parseTableRange reads "4", "1-3" or "91-00" into its bounds.
+func parseTableRange(cell string) (int, int, error) {
+	cell = strings.NewReplacer("–", "-", "—", "-", " ", "").Replace(cell)
+
+	lowBound, highBound, isRange := strings.Cut(cell, "-")
+	low, err := parseTableBound(lowBound)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return low, low, nil
+	}
+
+	high, err := parseTableBound(highBound)
+	if err != nil {
+		return 0, 0, err
+	}
+	if high < low {
+		return 0, 0, fmt.Errorf("invalid range %q", cell)
+	}
+	return low, high, nil
+}
+
+// parseTableBound reads a range bound, where "00" stands for 100.
+func parseTableBound(bound string) (int, error) {
+	if bound == "00" {
+		return 100, nil
+	}
+	value, err := strconv.Atoi(bound)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("invalid range bound %q", bound)
+	}
+	return value, nil
+}
+
+// TableResult is a roll on a random table with its entry expanded.
+type TableResult struct {
+	Table string
+	Die   int
+	Roll  int
+	Text  string
+	// Nested holds the rolls made on referenced tables, in order.
+	Nested []*TableResult
+}
+
+// TableRoller rolls on random tables, following table references and rolling the dice inside entries.
+type TableRoller struct {
+	Roller *Roller
+	Tables func(name string) (*Table, error)
+}
+
+// RollTable rolls on the named table and expands its entry.
+func (tr *TableRoller) RollTable(name string) (*TableResult, error) {
+	rolls := 0
+	return tr.rollTable(strings.ToLower(name), 0, &rolls)
+}
+
+func (tr *TableRoller) rollTable(name string, depth int, rolls *int) (*TableResult, error) {
+	if depth > MaxTableDepth {
+		return nil, fmt.Errorf("table references are nested deeper than %d levels", MaxTableDepth)
+	}
+	*rolls++
+	if *rolls > MaxTableRolls {
+		return nil, fmt.Errorf("more than %d tables rolled for a single result", MaxTableRolls)
+	}
+
+	table, err := tr.Tables(name)
+	if err != nil {
+		return nil, err
+	}
+
+	roll, row := table.Roll(tr.Roller.Source)
+	result := &TableResult{Table: table.Name, Die: table.Die(), Roll: roll}
+
+	var sb strings.Builder
+	last := 0
+	for _, ref := range tableRefPattern.FindAllStringSubmatchIndex(row.Text, -1) {
+		text, err := tr.rollEntryDice(row.Text[last:ref[0]])
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(text)
+
+		nested, err := tr.rollTable(strings.ToLower(row.Text[ref[2]:ref[3]]), depth+1, rolls)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(nested.Text)
+		result.Nested = append(result.Nested, nested)
+		last = ref[1]
+	}
+
+	text, err := tr.rollEntryDice(row.Text[last:])
+	if err != nil {
+		return nil, err
+	}
+	sb.WriteString(text)
+
+	result.Text = sb.String()
+	return result, nil
+}
+
+// rollEntryDice replaces the dice expressions inside an entry with their totals.
+func (tr *TableRoller) rollEntryDice(text string) (string, error) {
+	var rollErr error
+	text = entryDicePattern.ReplaceAllStringFunc(text, func(expr string) string {
+		result, err := tr.Roller.Evaluate(expr)
+		if err != nil {
+			rollErr = err
+			return expr
+		}
+		return strconv.Itoa(result.Total)
+	})
+	return text, rollErr
+}
@@ -0,0 +1,82 @@
+package dice
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTable(t *testing.T) {
+	t.Run("Markdown", func(t *testing.T) {
+		table, err := ParseTable("Encounters", "# Forest\n| d6 | Encounter |\n|---|:---:|\n| 1-3 | Goblins |\n| 4–5 | 2d4 wolves |\n| 6 | [[table:treasure]] |\n")
+		assert.NoError(t, err)
+		assert.Equal(t, "encounters", table.Name)
+		assert.Equal(t, 6, table.Die())
+		assert.Equal(t, []TableRow{{1, 3, "Goblins"}, {4, 5, "2d4 wolves"}, {6, 6, "[[table:treasure]]"}}, table.Rows)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		table, err := ParseTable("loot", "roll,item\n1-50,copper\n51-99,\"silver, polished\"\n00,gold, a lot of it\n")
+		assert.NoError(t, err)
+		assert.Equal(t, 100, table.Die())
+		assert.Equal(t, TableRow{51, 99, "silver, polished"}, table.Rows[1])
+		assert.Equal(t, TableRow{100, 100, "gold, a lot of it"}, table.Rows[2])
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		table, err := ParseTable("weather", "1-3 | Rain\n4 | Sun")
+		assert.NoError(t, err)
+		again, err := ParseTable("weather", table.String())
+		assert.NoError(t, err)
+		assert.Equal(t, table, again)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, content := range []string{"", "1-3 | a\n5-6 | b", "2-3 | a", "1-3 | a\nx | b", "3-1 | a", "1 |"} {
+			_, err := ParseTable("bad", content)
+			assert.Error(t, err, content)
+		}
+
+		_, err := ParseTable("9lives", "1 | a")
+		assert.Error(t, err)
+	})
+}
+
+func TestRollTable(t *testing.T) {
+	tables := map[string]string{
+		"encounters": "1 | 2d4 goblins with [[table:treasure]]\n2 | [[table:loop]]",
+		"treasure":   "1 | 1d6+1 gold\n2 | a gem",
+		"loop":       "1 | [[table:loop]]",
+	}
+	lookup := func(name string) (*Table, error) {
+		content, ok := tables[name]
+		if !ok {
+			return nil, fmt.Errorf("table %q is not defined", name)
+		}
+		return ParseTable(name, content)
+	}
+
+	t.Run("NestedAndDice", func(t *testing.T) {
+		// encounters 1, goblins 3+4, treasure 1, gold 5+1
+		roller := &TableRoller{Roller: NewRoller(&fixedSource{values: []int{1, 3, 4, 1, 5}}), Tables: lookup}
+		result, err := roller.RollTable("Encounters")
+		assert.NoError(t, err)
+		assert.Equal(t, "7 goblins with 6 gold", result.Text)
+		assert.Equal(t, 1, result.Roll)
+		assert.Len(t, result.Nested, 1)
+		assert.Equal(t, "treasure", result.Nested[0].Table)
+	})
+
+	t.Run("Loop", func(t *testing.T) {
+		roller := &TableRoller{Roller: NewRoller(&fixedSource{values: []int{2, 1}}), Tables: lookup}
+		_, err := roller.RollTable("encounters")
+		assert.ErrorContains(t, err, "nested deeper")
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		roller := &TableRoller{Roller: NewRoller(&fixedSource{values: []int{1}}), Tables: lookup}
+		_, err := roller.RollTable("dungeon")
+		assert.Error(t, err)
+	})
+}
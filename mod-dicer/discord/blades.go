@@ -0,0 +1,177 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+// handleBladesCommand rolls a Forged in the Dark dice pool, e.g. "blades 2 desperate great",
+// "blades resist 3" or "blades fortune 1".
+func (d *Discord) handleBladesCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
+	d.changeAvatar(s)
+
+	fields := strings.Fields(strings.ToLower(param))
+
+	variant := "action"
+	if len(fields) > 0 {
+		switch fields[0] {
+		case "resist", "resistance":
+			variant = "resist"
+			fields = fields[1:]
+		case "fortune":
+			variant = "fortune"
+			fields = fields[1:]
+		}
+	}
+
+	if len(fields) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Error: give the number of dice, e.g. `blades 2 risky standard`")
+		return
+	}
+
+	n, err := strconv.Atoi(fields[0])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "Error: the number of dice should be a number, e.g. `blades 2`")
+		return
+	}
+
+	pool, err := dice.RollPool(d.roller.Source, n)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	var embedMsg *embed.Embed
+	switch variant {
+	case "resist":
+		embedMsg = renderResistance(pool)
+	case "fortune":
+		embedMsg = renderFortune(pool)
+	default:
+		position, effect, err := parsePositionEffect(fields[1:])
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+			return
+		}
+		embedMsg = renderAction(pool, position, effect)
+	}
+
+	embedMsg.AddField(describePool(pool), fmt.Sprintf("`%dd6`", n)).SetColor(d.settings(m.GuildID).EmbedColor)
+	if pool.Zero {
+		embedMsg.SetFooter("Zero dice: rolled 2d6 and kept the lowest")
+	}
+
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+}
+
+// renderAction builds the embed of an action roll annotated with its position and effect.
+func renderAction(pool *dice.Pool, position, effect string) *embed.Embed {
+	outcome := pool.Outcome()
+
+	if outcome == dice.PoolCritical {
+		effect = dice.IncreasedEffect(effect)
+	}
+
+	return embed.NewEmbed().
+		SetTitle(fmt.Sprintf("%s %s", poolIcon(outcome), outcome)).
+		SetDescription(dice.Consequence(position, outcome)).
+		AddField(capitalize(position), "`position`").MakeFieldInline().
+		AddField(capitalize(effect), "`effect`").MakeFieldInline()
+}
+
+// renderResistance builds the embed of a resistance roll with its stress cost.
+func renderResistance(pool *dice.Pool) *embed.Embed {
+	stress := pool.Stress()
+
+	description := fmt.Sprintf("Take **%d** stress to resist the consequence.", stress)
+	switch {
+	case stress < 0:
+		description = "Critical: resist the consequence and **clear 1** stress."
+	case stress == 0:
+		description = "Resist the consequence at no stress cost."
+	}
+
+	return embed.NewEmbed().
+		SetTitle(fmt.Sprintf("🛡️ Resistance (%d)", pool.Result())).
+		SetDescription(description)
+}
+
+// renderFortune builds the embed of a fortune roll.
+func renderFortune(pool *dice.Pool) *embed.Embed {
+	outcome := pool.Outcome()
+
+	return embed.NewEmbed().
+		SetTitle(fmt.Sprintf("%s Fortune (%d)", poolIcon(outcome), pool.Result())).
+		SetDescription(outcome.Fortune())
+}
+
+// poolIcon returns the icon of a pool outcome.
+func poolIcon(outcome dice.PoolOutcome) string {
+	switch outcome {
+	case dice.PoolCritical:
+		return "💥"
+	case dice.PoolFull:
+		return "🟢"
+	case dice.PoolPartial:
+		return "🟡"
+	}
+	return "🔴"
+}
+
+// describePool lists the dice of a pool with the one that counts in bold.
+func describePool(pool *dice.Pool) string {
+	result := pool.Result()
+	marked := false
+
+	faces := make([]string, len(pool.Dice))
+	for i, die := range pool.Dice {
+		faces[i] = strconv.Itoa(die)
+		if die == result && !marked {
+			faces[i] = "**" + faces[i] + "**"
+			marked = true
+		}
+	}
+	return "(" + strings.Join(faces, ", ") + ")"
+}
+
+// parsePositionEffect reads the optional position and effect of an action roll, defaulting to risky and standard.
+func parsePositionEffect(fields []string) (string, string, error) {
+	position, effect := "risky", "standard"
+
+	for _, field := range fields {
+		switch {
+		case contains(dice.Positions, field):
+			position = field
+		case contains(dice.Effects, field):
+			effect = field
+		default:
+			return "", "", fmt.Errorf("unknown position or effect %q, use %s or %s", field, strings.Join(dice.Positions, "/"), strings.Join(dice.Effects, "/"))
+		}
+	}
+
+	return position, effect, nil
+}
+
+// contains reports whether the slice contains the value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// capitalize upper-cases the first letter of an ASCII word.
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
+}
@@ -0,0 +1,206 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+// handleFlipCommand flips one or more coins, e.g. "flip" or "flip 3".
+func (d *Discord) handleFlipCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
+	n := 1
+	if param != "" {
+		var err error
+		n, err = strconv.Atoi(param)
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "Error: the number of coins should be a number, e.g. `flip 3`")
+			return
+		}
+	}
+
+	coins, err := dice.FlipCoins(d.roller.Source, n)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	d.changeAvatar(s)
+
+	heads := 0
+	faces := make([]string, len(coins))
+	for i, coin := range coins {
+		faces[i] = "Tails"
+		if coin {
+			faces[i] = "Heads"
+			heads++
+		}
+	}
+
+	embedMsg := embed.NewEmbed().SetColor(d.settings(m.GuildID).EmbedColor)
+	if n == 1 {
+		embedMsg.SetTitle("🪙 " + faces[0])
+	} else {
+		embedMsg.SetTitle(fmt.Sprintf("🪙 %d heads, %d tails", heads, n-heads)).
+			SetDescription(strings.Join(faces, ", "))
+	}
+
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+}
+
+// handlePickCommand picks one of the given items, e.g. "pick tavern, dungeon, forest" or "pick voice".
+func (d *Discord) handlePickCommand(s *discordgo.Session, m *discordgo.MessageCreate, raw string) {
+	items, ok := d.choiceItems(s, m, raw, 0)
+	if !ok {
+		return
+	}
+
+	item, err := dice.Pick(d.roller.Source, items)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	d.changeAvatar(s)
+
+	embedMsg := embed.NewEmbed().
+		SetTitle("👉 Picked").
+		SetDescription("**" + item + "**").
+		SetFooter(fmt.Sprintf("out of %d", len(items))).
+		SetColor(d.settings(m.GuildID).EmbedColor).MessageEmbed
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg)
+}
+
+// handleShuffleCommand puts the given items in a random order, e.g. "shuffle @a @b @c" for a marching order.
+func (d *Discord) handleShuffleCommand(s *discordgo.Session, m *discordgo.MessageCreate, raw string) {
+	items, ok := d.choiceItems(s, m, raw, 0)
+	if !ok {
+		return
+	}
+	if len(items) < 2 || len(items) > dice.MaxChoices {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: give 2 to %d items to shuffle, e.g. `shuffle @a @b @c`", dice.MaxChoices))
+		return
+	}
+
+	d.changeAvatar(s)
+
+	dice.Shuffle(d.roller.Source, items)
+
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = fmt.Sprintf("%d. %s", i+1, item)
+	}
+
+	embedMsg := embed.NewEmbed().
+		SetTitle("🔀 Order").
+		SetDescription(strings.Join(lines, "\n")).
+		SetColor(d.settings(m.GuildID).EmbedColor).MessageEmbed
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg)
+}
+
+// handleTeamsCommand splits the given items into teams, e.g. "teams 2 @a @b @c @d" or "teams 3 voice".
+func (d *Discord) handleTeamsCommand(s *discordgo.Session, m *discordgo.MessageCreate, raw string) {
+	fields := strings.Fields(raw)
+	if len(fields) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Error: give the number of teams and the members, e.g. `teams 2 @a @b @c @d`")
+		return
+	}
+
+	n, err := strconv.Atoi(fields[0])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "Error: the number of teams should be a number, e.g. `teams 2 @a @b @c @d`")
+		return
+	}
+
+	// the first word is the number of teams
+	items, ok := d.choiceItems(s, m, raw, 1)
+	if !ok {
+		return
+	}
+
+	teams, err := dice.SplitTeams(d.roller.Source, n, items)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	d.changeAvatar(s)
+
+	embedMsg := embed.NewEmbed().SetTitle(fmt.Sprintf("👥 %d teams", n)).SetColor(d.settings(m.GuildID).EmbedColor)
+	for i, team := range teams {
+		embedMsg.AddField(fmt.Sprintf("Team %d", i+1), strings.Join(team, "\n")).MakeFieldInline()
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+}
+
+// choiceItems reads the items of the parameter after its first skip words, keeping their letter case. Items are separated
+// by commas, or by spaces when there is no comma, and the word "voice" stands for the members of the author's voice channel.
+func (d *Discord) choiceItems(s *discordgo.Session, m *discordgo.MessageCreate, raw string, skip int) ([]string, bool) {
+	for i := 0; i < skip; i++ {
+		raw = strings.TrimSpace(raw)
+		if end := strings.IndexAny(raw, " \t\n"); end >= 0 {
+			raw = raw[end:]
+		} else {
+			raw = ""
+		}
+	}
+
+	var fields []string
+	if strings.Contains(raw, ",") {
+		fields = strings.Split(raw, ",")
+	} else {
+		fields = strings.Fields(raw)
+	}
+
+	var items []string
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		switch {
+		case field == "":
+		case strings.EqualFold(field, "voice"):
+			members, err := d.voiceMembers(s, m)
+			if err != nil {
+				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+				return nil, false
+			}
+			items = append(items, members...)
+		default:
+			items = append(items, field)
+		}
+	}
+
+	return items, true
+}
+
+// voiceMembers returns the mentions of the members in the voice channel of the message author, bots excluded.
+func (d *Discord) voiceMembers(s *discordgo.Session, m *discordgo.MessageCreate) ([]string, error) {
+	if m.GuildID == "" {
+		return nil, fmt.Errorf("voice channels are only available in guilds")
+	}
+
+	voice, err := s.State.VoiceState(m.GuildID, m.Author.ID)
+	if err != nil || voice.ChannelID == "" {
+		return nil, fmt.Errorf("join a voice channel first")
+	}
+
+	guild, err := s.State.Guild(m.GuildID)
+	if err != nil {
+		return nil, fmt.Errorf("can't read the voice channel members")
+	}
+
+	s.State.RLock()
+	defer s.State.RUnlock()
+
+	var members []string
+	for _, state := range guild.VoiceStates {
+		if state.ChannelID != voice.ChannelID || (state.Member != nil && state.Member.User != nil && state.Member.User.Bot) {
+			continue
+		}
+		members = append(members, "<@"+state.UserID+">")
+	}
+	return members, nil
+}
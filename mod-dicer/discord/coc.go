@@ -0,0 +1,113 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+// handleCoCCommand rolls a Call of Cthulhu 7e skill check, e.g. "coc 45 bonus1" or "coc 60 penalty 2".
+func (d *Discord) handleCoCCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
+	d.changeAvatar(s)
+
+	skill, bonus, err := parseCoCParameter(param)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	result, err := dice.RollCoC(d.roller.Source, skill, bonus)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	s.ChannelMessageSendEmbed(m.ChannelID, renderCoCResult(result, d.settings(m.GuildID).EmbedColor))
+}
+
+// renderCoCResult builds the embed of a Call of Cthulhu skill roll.
+func renderCoCResult(result *dice.CoCResult, color int) *discordgo.MessageEmbed {
+	icon := "❌"
+	if result.Level.IsSuccess() {
+		icon = "✅"
+	}
+
+	tens := make([]string, len(result.Tens))
+	for i, t := range result.Tens {
+		tens[i] = fmt.Sprintf("%02d", t*10)
+		if len(result.Tens) > 1 && i == result.Chosen {
+			tens[i] = "**" + tens[i] + "**"
+		}
+	}
+
+	dicePool := "no bonus or penalty dice"
+	switch {
+	case result.Bonus > 0:
+		dicePool = fmt.Sprintf("%d bonus", result.Bonus)
+	case result.Bonus < 0:
+		dicePool = fmt.Sprintf("%d penalty", -result.Bonus)
+	}
+
+	return embed.NewEmbed().
+		SetTitle(fmt.Sprintf("%s %s", icon, result.Level)).
+		SetDescription(fmt.Sprintf("Rolled **%d** against skill %d", result.Value, result.Skill)).
+		AddField(strings.Join(tens, " / "), "`tens`").MakeFieldInline().
+		AddField(strconv.Itoa(result.Units), "`units`").MakeFieldInline().
+		AddField(fmt.Sprintf("%d / %d / %d", result.Skill, result.Skill/2, result.Skill/5), "`regular / hard / extreme`").MakeFieldInline().
+		SetFooter(dicePool).
+		SetColor(color).MessageEmbed
+}
+
+// parseCoCParameter reads the skill value and the net number of bonus dice.
+// Bonus and penalty dice are written as "bonus", "bonus2", "bonus 2", "penalty" and so on, and cancel each other out.
+func parseCoCParameter(param string) (int, int, error) {
+	fields := strings.Fields(strings.ToLower(param))
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("usage: `coc 45 bonus1`")
+	}
+
+	skill, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("skill value should be a number, e.g. `coc 45`")
+	}
+
+	bonus := 0
+	for i := 1; i < len(fields); i++ {
+		word := fields[i]
+
+		sign := 0
+		for prefix, s := range map[string]int{"bonus": 1, "penalty": -1} {
+			if strings.HasPrefix(word, prefix) {
+				sign = s
+				word = strings.TrimPrefix(word, prefix)
+			}
+		}
+		if sign == 0 {
+			return 0, 0, fmt.Errorf("unexpected %q, use `bonus` or `penalty`", fields[i])
+		}
+
+		if word == "" && i+1 < len(fields) {
+			if _, err := strconv.Atoi(fields[i+1]); err == nil {
+				i++
+				word = fields[i]
+			}
+		}
+
+		count := 1
+		if word != "" {
+			count, err = strconv.Atoi(word)
+			if err != nil || count < 1 {
+				return 0, 0, fmt.Errorf("invalid number of bonus or penalty dice in %q", fields[i])
+			}
+		}
+
+		bonus += sign * count
+	}
+
+	return skill, bonus, nil
+}
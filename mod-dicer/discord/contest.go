@@ -0,0 +1,426 @@
+package discord
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+const (
+	pendingButtonPrefix = "dicer:pending:"
+	maxParticipants     = 10
+)
+
+var (
+	mentionPattern = regexp.MustCompile(`^<@!?(\d+)>$`)
+	dcPattern      = regexp.MustCompile(`^dc(\d+)$`)
+)
+
+// handleVersusCommand starts an opposed roll, e.g. "vs @alice 1d20+5 @bob 1d20+3".
+func (d *Discord) handleVersusCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
+	participants, err := parseOpposedParticipants(param, d.defaultRoll(m))
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	d.startPendingRoll(s, m, &pendingRoll{
+		ID:           m.ID,
+		ChannelID:    m.ChannelID,
+		Color:        d.settings(m.GuildID).EmbedColor,
+		Kind:         opposedRoll,
+		Participants: participants,
+	})
+}
+
+// handleGroupCommand starts a group check, e.g. "group dc15 1d20+2 @a @b @c".
+func (d *Discord) handleGroupCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
+	dc, participants, err := parseGroupParticipants(param, d.defaultRoll(m))
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	d.startPendingRoll(s, m, &pendingRoll{
+		ID:           m.ID,
+		ChannelID:    m.ChannelID,
+		Color:        d.settings(m.GuildID).EmbedColor,
+		Kind:         groupRoll,
+		DC:           dc,
+		Participants: participants,
+	})
+}
+
+// startPendingRoll announces the pending roll with a roll button and waits for the participants.
+func (d *Discord) startPendingRoll(s *discordgo.Session, m *discordgo.MessageCreate, p *pendingRoll) {
+	pending, ok := d.pending(m.GuildID)
+	if !ok {
+		return
+	}
+
+	pending.add(p, pendingRollTimeout, func(expired *pendingRoll) {
+		d.finishPendingRoll(s, expired)
+	})
+
+	msg, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{renderPendingRoll(p)},
+		Components: pendingRollButtons(p.ID),
+	})
+	if err != nil {
+		slog.Errorf("Error announcing pending roll: %v", err)
+		pending.expire(p.ID)
+		return
+	}
+
+	pending.attach(p.ID, msg.ID)
+}
+
+// handlePendingReply records a roll when a participant replies to a pending roll announcement.
+// It reports whether the message was such a reply.
+func (d *Discord) handlePendingReply(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if m.MessageReference == nil {
+		return false
+	}
+
+	pending, ok := d.pending(m.GuildID)
+	if !ok {
+		return false
+	}
+
+	id, ok := pending.findByMessage(m.MessageReference.MessageID)
+	if !ok {
+		return false
+	}
+
+	p, resolved, err := pending.record(id, m.Author.ID, d.scopedRoller(scopeOf(m.GuildID, m.Author.ID)))
+	if err != nil {
+		s.ChannelMessageSendReply(m.ChannelID, fmt.Sprintf("Error: %v", err), m.Reference())
+		return true
+	}
+
+	if resolved {
+		d.finishPendingRoll(s, p)
+		return true
+	}
+
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         p.MessageID,
+		Channel:    p.ChannelID,
+		Embeds:     []*discordgo.MessageEmbed{renderPendingRoll(p)},
+		Components: pendingRollButtons(p.ID),
+	})
+	if err != nil {
+		slog.Errorf("Error updating pending roll: %v", err)
+	}
+	return true
+}
+
+// Interactions handles the roll buttons of pending rolls.
+func (d *Discord) Interactions(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+
+	pending, ok := d.pending(i.GuildID)
+	if !ok {
+		return
+	}
+
+	customID := i.MessageComponentData().CustomID
+	if !strings.HasPrefix(customID, pendingButtonPrefix) {
+		return
+	}
+
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+
+	roller := d.scopedRoller(scopeOf(i.GuildID, user.ID))
+	p, resolved, err := pending.record(strings.TrimPrefix(customID, pendingButtonPrefix), user.ID, roller)
+	if err != nil {
+		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("Error: %v", err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			slog.Errorf("Error responding to interaction: %v", err)
+		}
+		return
+	}
+
+	components := pendingRollButtons(p.ID)
+	if resolved {
+		components = []discordgo.MessageComponent{}
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{renderPendingRoll(p)},
+			Components: components,
+		},
+	})
+	if err != nil {
+		slog.Errorf("Error responding to interaction: %v", err)
+	}
+}
+
+// finishPendingRoll replaces the announcement with the final results and removes the roll button.
+func (d *Discord) finishPendingRoll(s *discordgo.Session, p *pendingRoll) {
+	if p.MessageID == "" {
+		return
+	}
+
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         p.MessageID,
+		Channel:    p.ChannelID,
+		Embeds:     []*discordgo.MessageEmbed{renderPendingRoll(p)},
+		Components: []discordgo.MessageComponent{},
+	})
+	if err != nil {
+		slog.Errorf("Error finishing pending roll: %v", err)
+	}
+}
+
+// pendingRollButtons returns the roll button of a pending roll.
+func pendingRollButtons(id string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Roll",
+					Emoji:    discordgo.ComponentEmoji{Name: "🎲"},
+					Style:    discordgo.PrimaryButton,
+					CustomID: pendingButtonPrefix + id,
+				},
+			},
+		},
+	}
+}
+
+// renderPendingRoll builds the embed of a pending roll in its current state.
+func renderPendingRoll(p *pendingRoll) *discordgo.MessageEmbed {
+	var title string
+	switch p.Kind {
+	case opposedRoll:
+		title = "⚔️ Opposed roll"
+	case groupRoll:
+		title = fmt.Sprintf("👥 Group check DC %d", p.DC)
+	}
+
+	lines := make([]string, 0, len(p.Participants))
+	for _, part := range p.Participants {
+		lines = append(lines, renderParticipant(p, part))
+	}
+
+	embedMsg := embed.NewEmbed().
+		SetTitle(title).
+		SetDescription(strings.Join(lines, "\n")).
+		SetColor(p.Color)
+
+	switch p.State {
+	case pendingCollecting:
+		embedMsg.SetFooter(fmt.Sprintf("Click Roll or reply to this message before %v", p.Deadline.Format("15:04:05 MST")))
+	default:
+		embedMsg.AddField("Result", pendingOutcome(p))
+		if p.State == pendingExpired {
+			embedMsg.SetFooter("Time is up")
+		}
+	}
+
+	return embedMsg.MessageEmbed
+}
+
+// renderParticipant describes a participant and their roll.
+func renderParticipant(p *pendingRoll, part *participant) string {
+	line := fmt.Sprintf("<@%s> `%s`", part.UserID, part.Expression)
+	if part.Result == nil {
+		if p.State == pendingCollecting {
+			return line + " — ⏳ waiting"
+		}
+		return line + " — did not roll"
+	}
+
+	line += fmt.Sprintf(" → **%d** (%s)", part.Result.Total, describeRolls(part.Result))
+	if p.Kind == groupRoll {
+		if part.Result.Total >= p.DC {
+			line += " ✅"
+		} else {
+			line += " ❌"
+		}
+	}
+	return line
+}
+
+// pendingOutcome announces the winners of an opposed roll or whether a group check passed.
+func pendingOutcome(p *pendingRoll) string {
+	switch p.Kind {
+	case opposedRoll:
+		var rolled []*participant
+		for _, part := range p.Participants {
+			if part.Result != nil {
+				rolled = append(rolled, part)
+			}
+		}
+
+		if len(rolled) == 0 {
+			return "Nobody rolled"
+		}
+
+		sort.SliceStable(rolled, func(i, j int) bool {
+			return rolled[i].Result.Total > rolled[j].Result.Total
+		})
+
+		var winners []string
+		for _, part := range rolled {
+			if part.Result.Total == rolled[0].Result.Total {
+				winners = append(winners, "<@"+part.UserID+">")
+			}
+		}
+
+		if len(winners) > 1 {
+			return fmt.Sprintf("Tie at **%d** between %s", rolled[0].Result.Total, strings.Join(winners, ", "))
+		}
+		return fmt.Sprintf("%s wins with **%d**", winners[0], rolled[0].Result.Total)
+
+	case groupRoll:
+		successes := 0
+		for _, part := range p.Participants {
+			if part.Result != nil && part.Result.Total >= p.DC {
+				successes++
+			}
+		}
+
+		if successes*2 >= len(p.Participants) {
+			return fmt.Sprintf("✅ Group passes (%d of %d succeeded)", successes, len(p.Participants))
+		}
+		return fmt.Sprintf("❌ Group fails (%d of %d succeeded)", successes, len(p.Participants))
+	}
+
+	return ""
+}
+
+// describeRolls lists the individual dice of a result, e.g. "3 + 5, +2".
+func describeRolls(result *dice.Result) string {
+	parts := make([]string, 0, len(result.Terms))
+	for _, term := range result.Terms {
+		if !term.IsDice() {
+			parts = append(parts, fmt.Sprintf("%+d", term.Total()))
+			continue
+		}
+
+		sign := ""
+		if term.Sign < 0 {
+			sign = "-"
+		}
+		parts = append(parts, sign+joinFaces(term))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseOpposedParticipants reads "@user expression" pairs, participants without an expression roll the default roll.
+func parseOpposedParticipants(param, defaultRoll string) ([]*participant, error) {
+	var participants []*participant
+
+	for _, field := range strings.Fields(param) {
+		if match := mentionPattern.FindStringSubmatch(field); match != nil {
+			participants = append(participants, &participant{UserID: match[1]})
+			continue
+		}
+
+		if len(participants) == 0 {
+			return nil, fmt.Errorf("start with a participant mention, e.g. `vs @alice 1d20+5 @bob 1d20+3`")
+		}
+
+		last := participants[len(participants)-1]
+		last.Expression = strings.TrimSpace(last.Expression + " " + field)
+	}
+
+	if len(participants) < 2 {
+		return nil, fmt.Errorf("an opposed roll needs at least two participants")
+	}
+
+	for _, part := range participants {
+		if part.Expression == "" {
+			part.Expression = defaultRoll
+		}
+	}
+
+	return validateParticipants(participants)
+}
+
+// parseGroupParticipants reads "dcN expression @user @user ...", the expression is the default roll when missing.
+func parseGroupParticipants(param, defaultRoll string) (int, []*participant, error) {
+	fields := strings.Fields(param)
+	if len(fields) == 0 {
+		return 0, nil, fmt.Errorf("usage: `group dc15 1d20+2 @a @b @c`")
+	}
+
+	match := dcPattern.FindStringSubmatch(fields[0])
+	if match == nil {
+		return 0, nil, fmt.Errorf("a group check starts with its difficulty, e.g. `dc15`")
+	}
+	dc, _ := strconv.Atoi(match[1])
+
+	var expression []string
+	var participants []*participant
+	for _, field := range fields[1:] {
+		if mention := mentionPattern.FindStringSubmatch(field); mention != nil {
+			participants = append(participants, &participant{UserID: mention[1]})
+			continue
+		}
+
+		if len(participants) > 0 {
+			return 0, nil, fmt.Errorf("put the dice before the participants, e.g. `group dc15 1d20+2 @a @b @c`")
+		}
+		expression = append(expression, field)
+	}
+
+	if len(participants) == 0 {
+		return 0, nil, fmt.Errorf("a group check needs at least one participant")
+	}
+
+	expr := strings.Join(expression, " ")
+	if expr == "" {
+		expr = defaultRoll
+	}
+	for _, part := range participants {
+		part.Expression = expr
+	}
+
+	participants, err := validateParticipants(participants)
+	return dc, participants, err
+}
+
+// validateParticipants checks the expressions and rejects duplicates and oversized groups.
+func validateParticipants(participants []*participant) ([]*participant, error) {
+	if len(participants) > maxParticipants {
+		return nil, fmt.Errorf("up to %d participants can take part in a roll", maxParticipants)
+	}
+
+	seen := make(map[string]bool)
+	for _, part := range participants {
+		if seen[part.UserID] {
+			return nil, fmt.Errorf("<@%s> is listed more than once", part.UserID)
+		}
+		seen[part.UserID] = true
+
+		if _, err := dice.Parse(part.Expression); err != nil {
+			return nil, fmt.Errorf("invalid dice for <@%s>: %v", part.UserID, err)
+		}
+	}
+
+	return participants, nil
+}
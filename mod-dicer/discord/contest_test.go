@@ -0,0 +1,228 @@
+package discord
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+func newPendingRoll(id string, kind pendingKind, expressions ...string) *pendingRoll {
+	p := &pendingRoll{ID: id, Kind: kind}
+	for i, expression := range expressions {
+		p.Participants = append(p.Participants, &participant{UserID: string(rune('a' + i)), Expression: expression})
+	}
+	return p
+}
+
+func TestPendingRolls(t *testing.T) {
+	roller := dice.NewRoller(dice.NewSeededSource(1))
+	never := func(*pendingRoll) { t.Error("the roll expired") }
+
+	t.Run("Record", func(t *testing.T) {
+		pr := newPendingRolls()
+		pr.add(newPendingRoll("vs", opposedRoll, "1d20+5", "1d20"), time.Minute, never)
+
+		tests := []struct {
+			name     string
+			userID   string
+			resolved bool
+			err      string
+		}{
+			{"FirstRoll", "a", false, ""},
+			{"AlreadyRolled", "a", false, "you have already rolled"},
+			{"NotTakingPart", "z", false, "you are not taking part in this roll"},
+			{"LastRoll", "b", true, ""},
+			{"AlreadyOver", "b", false, "this roll is already over"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				p, resolved, err := pr.record("vs", tt.userID, roller)
+				if tt.err != "" {
+					assert.EqualError(t, err, tt.err)
+					return
+				}
+				assert.NoError(t, err)
+				assert.Equal(t, tt.resolved, resolved)
+				assert.NotNil(t, p.participant(tt.userID).Result)
+			})
+		}
+		assert.Empty(t, pr.rolls)
+	})
+
+	t.Run("InvalidDice", func(t *testing.T) {
+		pr := newPendingRolls()
+		pr.add(newPendingRoll("vs", opposedRoll, "1d{missing}", "1d20"), time.Minute, never)
+
+		_, _, err := pr.record("vs", "a", roller)
+		assert.Error(t, err)
+		_, _, err = pr.record("vs", "a", roller)
+		assert.Error(t, err, "a failed roll can be tried again")
+		assert.NotEqual(t, "you have already rolled", err.Error())
+		pr.clear()
+	})
+
+	t.Run("EvaluatesWithoutLock", func(t *testing.T) {
+		pr := newPendingRolls()
+		pr.add(newPendingRoll("vs", opposedRoll, "1d{slow}", "1d20"), time.Minute, never)
+
+		looking, release := make(chan struct{}), make(chan struct{})
+		slow := &dice.Roller{Source: dice.NewSeededSource(1), Faces: func(name string) (*dice.FaceDie, error) {
+			close(looking)
+			<-release
+			return dice.ParseFaceList(name, "[hit, miss]")
+		}}
+		done := make(chan bool)
+		go func() {
+			_, resolved, err := pr.record("vs", "a", slow)
+			assert.NoError(t, err)
+			done <- resolved
+		}()
+		<-looking
+
+		// the slow lookup of a doesn't hold up b, nor lets a roll twice
+		_, resolved, err := pr.record("vs", "b", roller)
+		assert.NoError(t, err)
+		assert.False(t, resolved)
+		_, _, err = pr.record("vs", "a", roller)
+		assert.EqualError(t, err, "you have already rolled")
+
+		close(release)
+		assert.True(t, <-done)
+	})
+
+	t.Run("Expire", func(t *testing.T) {
+		pr := newPendingRolls()
+		expired := make(chan *pendingRoll, 1)
+		pr.add(newPendingRoll("group", groupRoll, "1d20", "1d20"), 10*time.Millisecond, func(p *pendingRoll) { expired <- p })
+		_, _, err := pr.record("group", "a", roller)
+		assert.NoError(t, err)
+
+		select {
+		case p := <-expired:
+			assert.Equal(t, pendingExpired, p.State)
+		case <-time.After(time.Second):
+			t.Fatal("the roll didn't expire")
+		}
+		_, _, err = pr.record("group", "b", roller)
+		assert.EqualError(t, err, "this roll is already over")
+		assert.False(t, pr.expire("group"), "a roll expires once")
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		pr := newPendingRolls()
+		p := newPendingRoll("vs", opposedRoll, "1d20", "1d20")
+		pr.add(p, 10*time.Millisecond, never)
+		pr.clear()
+
+		assert.Empty(t, pr.rolls)
+		assert.Equal(t, pendingExpired, p.State)
+		time.Sleep(20 * time.Millisecond)
+	})
+}
+
+func TestParseOpposedParticipants(t *testing.T) {
+	tests := []struct {
+		param string
+		want  []participant
+		err   string
+	}{
+		{"<@1> 1d20+5 <@2> 1d20+3", []participant{{UserID: "1", Expression: "1d20+5"}, {UserID: "2", Expression: "1d20+3"}}, ""},
+		{"<@!1> 2d6 1d4 <@2>", []participant{{UserID: "1", Expression: "2d6 1d4"}, {UserID: "2", Expression: "1d20"}}, ""},
+		{"1d20 <@1> <@2>", nil, "start with a participant mention, e.g. `vs @alice 1d20+5 @bob 1d20+3`"},
+		{"<@1> 1d20", nil, "an opposed roll needs at least two participants"},
+		{"<@1> <@1>", nil, "<@1> is listed more than once"},
+		{"<@1> banana <@2>", nil, "invalid dice for <@1>"},
+		{"<@1> <@2> <@3> <@4> <@5> <@6> <@7> <@8> <@9> <@10> <@11>", nil, "up to 10 participants can take part in a roll"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			participants, err := parseOpposedParticipants(tt.param, "1d20")
+			if tt.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, participants, len(tt.want)) {
+				for i, part := range participants {
+					assert.Equal(t, tt.want[i], *part)
+				}
+			}
+		})
+	}
+}
+
+func TestParseGroupParticipants(t *testing.T) {
+	tests := []struct {
+		param string
+		dc    int
+		want  string
+		users int
+		err   string
+	}{
+		{"dc15 1d20+2 <@1> <@2> <@3>", 15, "1d20+2", 3, ""},
+		{"dc12 <@1>", 12, "1d20", 1, ""},
+		{"", 0, "", 0, "usage: `group dc15 1d20+2 @a @b @c`"},
+		{"1d20 <@1>", 0, "", 0, "a group check starts with its difficulty, e.g. `dc15`"},
+		{"dc15 1d20", 0, "", 0, "a group check needs at least one participant"},
+		{"dc15 <@1> 1d20", 0, "", 0, "put the dice before the participants, e.g. `group dc15 1d20+2 @a @b @c`"},
+		{"dc15 banana <@1>", 0, "", 0, "invalid dice for <@1>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			dc, participants, err := parseGroupParticipants(tt.param, "1d20")
+			if tt.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.dc, dc)
+			if assert.Len(t, participants, tt.users) {
+				for _, part := range participants {
+					assert.Equal(t, tt.want, part.Expression)
+				}
+			}
+		})
+	}
+}
+
+func TestPendingOutcome(t *testing.T) {
+	group := func(totals ...int) *pendingRoll {
+		p := &pendingRoll{Kind: groupRoll, DC: 10}
+		for i, total := range totals {
+			part := &participant{UserID: string(rune('a' + i))}
+			if total > 0 {
+				part.Result = &dice.Result{Total: total}
+			}
+			p.Participants = append(p.Participants, part)
+		}
+		return p
+	}
+
+	t.Run("Group", func(t *testing.T) {
+		assert.Equal(t, "✅ Group passes (2 of 4 succeeded)", pendingOutcome(group(10, 15, 9, 2)), "half succeeding is enough")
+		assert.Equal(t, "❌ Group fails (1 of 3 succeeded)", pendingOutcome(group(10, 9, 2)))
+		assert.Equal(t, "✅ Group passes (2 of 3 succeeded)", pendingOutcome(group(12, 20, 0)))
+		assert.Equal(t, "❌ Group fails (1 of 3 succeeded)", pendingOutcome(group(12, 0, 0)), "participants who didn't roll fail")
+	})
+
+	t.Run("Opposed", func(t *testing.T) {
+		p := &pendingRoll{Kind: opposedRoll, Participants: []*participant{
+			{UserID: "1", Result: &dice.Result{Total: 14}},
+			{UserID: "2", Result: &dice.Result{Total: 18}},
+			{UserID: "3"},
+		}}
+		assert.Equal(t, "<@2> wins with **18**", pendingOutcome(p))
+
+		p.Participants[0].Result.Total = 18
+		assert.Equal(t, "Tie at **18** between <@1>, <@2>", pendingOutcome(p))
+
+		assert.Equal(t, "Nobody rolled", pendingOutcome(&pendingRoll{Kind: opposedRoll, Participants: []*participant{{UserID: "1"}}}))
+	})
+}
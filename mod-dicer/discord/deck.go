@@ -0,0 +1,283 @@
+package discord
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/mod-dicer/deck"
+)
+
+// dealPattern matches an initiative participant with an optional number of cards, e.g. "<@123>:2" or "goblins".
+var dealPattern = regexp.MustCompile(`^(<@!?\d+>|[^:\s]+)(?::(\d+))?$`)
+
+// handleDeckCommand handles the card deck of the channel, e.g. "deck new tarot", "deck draw 3",
+// "deck discard", "deck shuffle" or "deck deal @alice @bob:2 goblins".
+func (d *Discord) handleDeckCommand(s *discordgo.Session, m *discordgo.MessageCreate, raw string) {
+	fields := strings.Fields(strings.ToLower(raw))
+	if len(fields) == 0 {
+		d.showDeck(s, m)
+		return
+	}
+
+	lock := d.deckLock(m.ChannelID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	switch fields[0] {
+	case "new":
+		d.handleDeckNew(s, m, raw)
+	case "draw":
+		d.handleDeckDraw(s, m, fields[1:])
+	case "discard":
+		d.updateDeck(s, m, func(cards *deck.Deck) string {
+			n := cards.Discard()
+			return fmt.Sprintf("%d cards discarded, %d left in the deck", n, len(cards.Pile))
+		})
+	case "shuffle":
+		d.updateDeck(s, m, func(cards *deck.Deck) string {
+			cards.Shuffle(d.roller.Source)
+			return fmt.Sprintf("🔀 Deck shuffled, %d cards", len(cards.Pile))
+		})
+	case "deal", "initiative":
+		d.handleDeckDeal(s, m, fields[1:])
+	default:
+		s.ChannelMessageSend(m.ChannelID, "Error: use `deck new`, `deck draw`, `deck discard`, `deck shuffle` or `deck deal`")
+	}
+}
+
+// deckLock returns the lock of the deck of the channel.
+func (d *Discord) deckLock(channelID string) *sync.Mutex {
+	lock, _ := d.deckLocks.LoadOrStore(channelID, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+// handleDeckNew replaces the deck of the channel with a new shuffled one, e.g. "deck new poker" or "deck new [Sun, Moon, Star]".
+func (d *Discord) handleDeckNew(s *discordgo.Session, m *discordgo.MessageCreate, raw string) {
+	// keep the letter case of custom card names
+	kind := strings.TrimSpace(strings.Join(strings.Fields(raw)[1:], " "))
+	if kind == "" {
+		kind = deck.Poker
+	}
+
+	var names []string
+	if strings.HasPrefix(kind, "[") {
+		if !strings.HasSuffix(kind, "]") {
+			s.ChannelMessageSend(m.ChannelID, "Error: custom decks are given as a list, e.g. `deck new [Sun, Moon, Star]`")
+			return
+		}
+		for _, name := range strings.Split(strings.Trim(kind, "[]"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		kind = deck.Custom
+	}
+
+	cards, err := deck.New(d.roller.Source, strings.ToLower(kind), names)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	if !d.saveDeck(s, m, cards) {
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🃏 New %s deck of %d cards shuffled", kind, cards.Size()))
+}
+
+// handleDeckDraw draws cards from the deck of the channel, e.g. "deck draw 3".
+func (d *Discord) handleDeckDraw(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	n := 1
+	if len(fields) > 0 {
+		var err error
+		n, err = strconv.Atoi(fields[0])
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "Error: the number of cards should be a number, e.g. `deck draw 3`")
+			return
+		}
+	}
+
+	cards, ok := d.loadDeck(s, m)
+	if !ok {
+		return
+	}
+
+	d.changeAvatar(s)
+
+	drawn, err := cards.Draw(d.roller.Source, n)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+	if !d.saveDeck(s, m, cards) {
+		return
+	}
+
+	names := make([]string, len(drawn))
+	for i, card := range drawn {
+		names[i] = "**" + cards.Name(card) + "**"
+	}
+
+	embedMsg := embed.NewEmbed().
+		SetTitle(fmt.Sprintf("🃏 %s drew %d card(s)", m.Author.Username, len(drawn))).
+		SetDescription(strings.Join(names, "\n")).
+		SetFooter(fmt.Sprintf("%d cards left in the %s deck", len(cards.Pile), cards.Kind)).
+		SetColor(d.settings(m.GuildID).EmbedColor).MessageEmbed
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg)
+}
+
+// handleDeckDeal deals initiative cards Deadlands-style, e.g. "deck deal @alice @bob:2 goblins".
+// A ":N" suffix deals N cards and keeps the best one.
+func (d *Discord) handleDeckDeal(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	if len(fields) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Error: give the participants, e.g. `deck deal @alice @bob:2 goblins`")
+		return
+	}
+
+	names := make([]string, len(fields))
+	counts := make([]int, len(fields))
+	for i, field := range fields {
+		match := dealPattern.FindStringSubmatch(field)
+		if match == nil {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: invalid participant %q, e.g. `@bob:2` or `goblins`", field))
+			return
+		}
+
+		names[i], counts[i] = match[1], 1
+		if match[2] != "" {
+			counts[i], _ = strconv.Atoi(match[2])
+		}
+		if counts[i] < 1 || counts[i] > deck.MaxDraw {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: each participant draws 1 to %d cards", deck.MaxDraw))
+			return
+		}
+	}
+
+	cards, ok := d.loadDeck(s, m)
+	if !ok {
+		return
+	}
+
+	d.changeAvatar(s)
+
+	deals, err := cards.DealInitiative(d.roller.Source, names, counts)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+	if !d.saveDeck(s, m, cards) {
+		return
+	}
+
+	var sb strings.Builder
+	joker := false
+	for i, deal := range deals {
+		fmt.Fprintf(&sb, "%d. %s **%s**", i+1, deal.Name, cards.Name(deal.Best))
+		if len(deal.Cards) > 1 {
+			others := make([]string, 0, len(deal.Cards)-1)
+			for _, card := range deal.Cards {
+				if card != deal.Best {
+					others = append(others, cards.Name(card))
+				}
+			}
+			fmt.Fprintf(&sb, " (also %s)", strings.Join(others, ", "))
+		}
+		sb.WriteString("\n")
+		joker = joker || cards.IsJoker(deal.Best)
+	}
+
+	footer := fmt.Sprintf("%d cards left in the deck", len(cards.Pile))
+	if joker {
+		footer = "A joker was dealt: shuffle the deck at the end of the round · " + footer
+	}
+
+	embedMsg := embed.NewEmbed().
+		SetTitle("⚔️ Initiative").
+		SetDescription(sb.String()).
+		SetFooter(footer).
+		SetColor(d.settings(m.GuildID).EmbedColor).MessageEmbed
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg)
+}
+
+// showDeck shows the state of the deck of the channel and the cards in play.
+func (d *Discord) showDeck(s *discordgo.Session, m *discordgo.MessageCreate) {
+	cards, ok := d.loadDeck(s, m)
+	if !ok {
+		return
+	}
+
+	inPlay := make([]string, len(cards.InPlay))
+	for i, card := range cards.InPlay {
+		inPlay[i] = cards.Name(card)
+	}
+
+	embedMsg := embed.NewEmbed().
+		SetTitle(fmt.Sprintf("🃏 %s deck", capitalize(cards.Kind))).
+		AddField(strconv.Itoa(len(cards.Pile)), "`in the deck`").MakeFieldInline().
+		AddField(strconv.Itoa(len(cards.InPlay)), "`in play`").MakeFieldInline().
+		AddField(strconv.Itoa(len(cards.Discards)), "`discarded`").MakeFieldInline().
+		SetColor(d.settings(m.GuildID).EmbedColor)
+	if len(inPlay) > 0 {
+		embedMsg.SetDescription(strings.Join(inPlay, ", "))
+	}
+
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+}
+
+// updateDeck loads the deck of the channel, applies the change and saves it, replying with the returned message.
+func (d *Discord) updateDeck(s *discordgo.Session, m *discordgo.MessageCreate, change func(*deck.Deck) string) {
+	cards, ok := d.loadDeck(s, m)
+	if !ok {
+		return
+	}
+
+	message := change(cards)
+	if !d.saveDeck(s, m, cards) {
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, message)
+}
+
+// loadDeck loads the deck of the channel, replying with an error when there is none.
+func (d *Discord) loadDeck(s *discordgo.Session, m *discordgo.MessageCreate) (*deck.Deck, bool) {
+	stored, err := db.GetChannelDeck(m.ChannelID)
+	if err != nil {
+		slog.Errorf("Error loading deck: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error loading the deck")
+		return nil, false
+	}
+	if stored == nil {
+		s.ChannelMessageSend(m.ChannelID, "No deck in this channel yet, start one with `deck new poker`, `deck new jokers` or `deck new tarot`")
+		return nil, false
+	}
+
+	cards, err := deck.Load(stored.State)
+	if err != nil {
+		slog.Errorf("Error reading deck: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error loading the deck, start a new one with `deck new`")
+		return nil, false
+	}
+	return cards, true
+}
+
+// saveDeck saves the deck of the channel, replying with an error when it fails.
+func (d *Discord) saveDeck(s *discordgo.Session, m *discordgo.MessageCreate, cards *deck.Deck) bool {
+	state, err := cards.Save()
+	if err == nil {
+		err = db.SaveChannelDeck(db.ChannelDeck{ChannelID: m.ChannelID, State: state})
+	}
+	if err != nil {
+		slog.Errorf("Error saving deck: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error saving the deck")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+const maxCustomDice = 50
+
+// restrictDefine restricts defining a die of the guild, listing them is open to everyone.
+func restrictDefine(param string) string {
+	if strings.TrimSpace(param) == "" {
+		return ""
+	}
+	return "define"
+}
+
+// handleDefineCommand defines a custom die, e.g. "define loot [copper, copper, silver, gold:2]", or lists them.
+func (d *Discord) handleDefineCommand(s *discordgo.Session, m *discordgo.MessageCreate, raw string) {
+	name, faces, _ := strings.Cut(strings.TrimSpace(raw), " ")
+	if name == "" {
+		d.listCustomDice(s, m)
+		return
+	}
+
+	if strings.TrimSpace(faces) == "" {
+		s.ChannelMessageSend(m.ChannelID, "Error: give the faces of the die, e.g. `define loot [copper, copper, silver, gold:2]`")
+		return
+	}
+
+	die, err := dice.ParseFaceList(name, faces)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	scopeID := scopeOf(m.GuildID, m.Author.ID)
+	existing, err := db.GetCustomDice(scopeID)
+	if err != nil {
+		slog.Errorf("Error loading custom dice: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error saving the die")
+		return
+	}
+	if len(existing) >= maxCustomDice && !hasCustomDie(existing, die.Name) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: up to %d custom dice can be defined, `undefine` some first", maxCustomDice))
+		return
+	}
+
+	err = db.SaveCustomDie(db.CustomDie{ScopeID: scopeID, Name: die.Name, Faces: formatFaceList(die)})
+	if err != nil {
+		slog.Errorf("Error saving custom die: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error saving the die")
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Die `%s` defined with %d faces, roll it with `%sroll 1d{%s}`", die.Name, len(die.Faces), d.settings(m.GuildID).Prefix, die.Name))
+}
+
+// handleUndefineCommand removes a custom die.
+func (d *Discord) handleUndefineCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
+	if param == "" {
+		s.ChannelMessageSend(m.ChannelID, "Error: give the name of the die, e.g. `undefine loot`")
+		return
+	}
+
+	found, err := db.DeleteCustomDie(scopeOf(m.GuildID, m.Author.ID), param)
+	if err != nil {
+		slog.Errorf("Error deleting custom die: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error removing the die")
+		return
+	}
+
+	if !found {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Die `%s` is not defined", param))
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Die `%s` removed", param))
+}
+
+// listCustomDice shows the custom dice of the guild or user.
+func (d *Discord) listCustomDice(s *discordgo.Session, m *discordgo.MessageCreate) {
+	customDice, err := db.GetCustomDice(scopeOf(m.GuildID, m.Author.ID))
+	if err != nil {
+		slog.Errorf("Error loading custom dice: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error loading custom dice")
+		return
+	}
+
+	if len(customDice) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "No custom dice yet, define one with e.g. `define loot [copper, copper, silver, gold:2]`")
+		return
+	}
+
+	embedMsg := embed.NewEmbed().SetTitle("Custom dice").SetColor(d.settings(m.GuildID).EmbedColor)
+	for _, die := range customDice {
+		embedMsg.AddField("`"+die.Name+"`", die.Faces)
+	}
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+}
+
+// scopedRoller returns a roller that can roll the custom dice of the guild or user.
+func (d *Discord) scopedRoller(scopeID string) *dice.Roller {
+	return d.roller.WithFaces(func(name string) (*dice.FaceDie, error) {
+		stored, err := db.GetCustomDie(scopeID, name)
+		if err != nil {
+			slog.Errorf("Error loading custom die: %v", err)
+			return nil, fmt.Errorf("can't load die %q", name)
+		}
+		if stored == nil {
+			return nil, fmt.Errorf("die %q is not defined, see `define`", name)
+		}
+		return dice.ParseFaceList(stored.Name, stored.Faces)
+	})
+}
+
+// formatFaceList writes the faces of a die back in the notation accepted by define.
+func formatFaceList(die *dice.FaceDie) string {
+	faces := make([]string, len(die.Faces))
+	for i, face := range die.Faces {
+		faces[i] = face.Label
+		if face.Weight > 0 {
+			faces[i] += fmt.Sprintf(":%d", face.Weight)
+		}
+	}
+	return "[" + strings.Join(faces, ", ") + "]"
+}
+
+// hasCustomDie reports whether a die with the given name is in the list.
+func hasCustomDie(customDice []db.CustomDie, name string) bool {
+	for _, die := range customDice {
+		if die.Name == name {
+			return true
+		}
+	}
+	return false
+}
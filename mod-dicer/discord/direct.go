@@ -0,0 +1,67 @@
+package discord
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+// handleDefaultCommand shows or changes the default roll of a user in direct messages.
+func (d *Discord) handleDefaultCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
+	if m.GuildID != "" {
+		s.ChannelMessageSend(m.ChannelID, "Default roll can only be changed in direct messages.")
+		return
+	}
+
+	if param == "" {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Your default roll is `%v`.", d.defaultRoll(m)))
+		return
+	}
+
+	if _, err := dice.Parse(param); err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	err := db.SaveUserSettings(db.UserSettings{UserID: m.Author.ID, DefaultRoll: param})
+	if err != nil {
+		slog.Errorf("Error saving user settings: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error saving default roll")
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Default roll set to `%v`.", param))
+}
+
+// defaultRoll returns the roll used when no dice are given: the user's own one in direct messages,
+// or the default roll of the guild.
+func (d *Discord) defaultRoll(m *discordgo.MessageCreate) string {
+	fallback := d.settings(m.GuildID).DefaultRoll
+	if m.GuildID != "" {
+		return fallback
+	}
+
+	settings, err := db.GetUserSettings(m.Author.ID)
+	if err != nil {
+		slog.Errorf("Error loading user settings: %v", err)
+		return fallback
+	}
+
+	if settings == nil || settings.DefaultRoll == "" {
+		return fallback
+	}
+
+	return settings.DefaultRoll
+}
+
+// scopeOf returns the ID per-guild data is stored under: the guild, or the given user in direct messages.
+func scopeOf(guildID, userID string) string {
+	if guildID == "" {
+		return userID
+	}
+	return guildID
+}
@@ -0,0 +1,54 @@
+package discord
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/internal/router"
+	"github.com/keshon/dice-roller/internal/settings"
+)
+
+func TestDefaultRoll(t *testing.T) {
+	_, err := db.InitDB("file::memory:")
+	if !assert.NoError(t, err) {
+		return
+	}
+	r := router.New(&discordgo.Session{}, "dice ")
+	r.Settings = settings.NewStore(settings.Defaults("dice "))
+	d := &Discord{router: r}
+	_, err = r.Settings.Set("guild", "roll", "2d6")
+	assert.NoError(t, err)
+
+	message := func(guildID, userID string) *discordgo.MessageCreate {
+		return &discordgo.MessageCreate{Message: &discordgo.Message{GuildID: guildID, Author: &discordgo.User{ID: userID}}}
+	}
+
+	assert.NoError(t, db.SaveUserSettings(db.UserSettings{UserID: "alice", DefaultRoll: "1d100"}))
+	assert.NoError(t, db.SaveUserSettings(db.UserSettings{UserID: "carol"}))
+
+	tests := []struct {
+		name    string
+		guildID string
+		userID  string
+		want    string
+	}{
+		{"UserRoll", "", "alice", "1d100"},
+		{"NoUserSettings", "", "bob", settings.DefaultRoll},
+		{"EmptyUserRoll", "", "carol", settings.DefaultRoll},
+		{"GuildRoll", "guild", "alice", "2d6"},
+		{"GuildDefault", "other", "alice", settings.DefaultRoll},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, d.defaultRoll(message(tt.guildID, tt.userID)))
+		})
+	}
+}
+
+func TestScopeOf(t *testing.T) {
+	assert.Equal(t, "guild", scopeOf("guild", "alice"))
+	assert.Equal(t, "alice", scopeOf("", "alice"), "direct messages keep data per user")
+}
@@ -1,13 +1,17 @@
 package discord
 
 import (
+	"context"
 	"fmt"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/gookit/slog"
-	"github.com/keshon/dice-roller/internal/config"
+	"github.com/keshon/dice-roller/internal/lifecycle"
+	"github.com/keshon/dice-roller/internal/router"
+	"github.com/keshon/dice-roller/internal/settings"
+	"github.com/keshon/dice-roller/mod-dicer/dice"
 	"github.com/keshon/dice-roller/mod-dicer/utils"
 )
 
@@ -16,142 +20,260 @@ type BotInstance struct {
 	DiceRoller *Discord
 }
 
-// Discord represents the Melodix instance for Discord.
+// Discord represents the Melodix instance for Discord. One instance serves every guild it is started in,
+// and direct messages.
 type Discord struct {
 	Session              *discordgo.Session
-	GuildID              string
-	IsInstanceActive     bool
-	prefix               string
+	avatarMu             sync.Mutex
 	lastChangeAvatarTime time.Time
 	rateLimitDuration    time.Duration
+	roller               *dice.Roller
+	router               *router.Router
+	guilds               *lifecycle.Guilds[*pendingRolls]
+	// deckLocks hold a *sync.Mutex by channel ID, so the deck commands of a channel don't interleave
+	// while other channels go on.
+	deckLocks sync.Map
 }
 
-// NewDiscord creates a new instance of Discord.
-func NewDiscord(session *discordgo.Session) *Discord {
-	config, err := config.NewConfig()
-	if err != nil {
-		slog.Fatalf("Error loading config: %v", err)
-	}
-
-	return &Discord{
+// NewDiscord creates a new instance of Discord and mounts its commands and handlers, shared by the guilds it serves.
+func NewDiscord(session *discordgo.Session, r *router.Router) *Discord {
+	d := &Discord{
 		Session:           session,
-		IsInstanceActive:  true,
-		prefix:            config.DiscordCommandPrefix,
 		rateLimitDuration: time.Minute * 10,
-	}
-}
-
-// Start starts the Discord instance.
-func (d *Discord) Start(guildID string) {
-	slog.Infof(`Discord instance started for guild id %v`, guildID)
-
-	d.Session.AddHandler(d.Commands)
-	d.GuildID = guildID
-}
-
-func (d *Discord) Stop() {
-	d.IsInstanceActive = false
-}
-
-// Commands handles incoming Discord commands.
-func (d *Discord) Commands(s *discordgo.Session, m *discordgo.MessageCreate) {
-	if m.GuildID != d.GuildID {
-		return
+		roller:            dice.NewRoller(dice.Crypto),
+		router:            r,
+		guilds:            lifecycle.NewGuilds(func(string) *pendingRolls { return newPendingRolls() }),
 	}
 
-	if !d.IsInstanceActive {
-		return
+	if r.Settings != nil {
+		r.Settings.Validate("roll", func(value string) error {
+			_, err := dice.Parse(value)
+			return err
+		})
 	}
 
-	slog.Info("User input: ", m.Message.Content)
+	r.Mount(router.Global, router.Module{Name: "dicer", Commands: d.commands(), Listener: d.listen, Serves: d.guilds.Serves})
+	session.AddHandler(d.Interactions)
+	return d
+}
 
-	command, parameter, err := parseCommand(m.Message.Content, d.prefix)
+// Start serves the guild, or direct messages for router.Direct, until Stop is called or the context is done.
+func (d *Discord) Start(ctx context.Context, guildID string) error {
+	guild, err := d.guilds.Start(ctx, guildID)
 	if err != nil {
-		return
+		return err
 	}
 
-	slog.Warnf("Command: %v, Parameter: %v", command, parameter)
-
-	commandAliases := [][]string{
-		{"roll", "r"},
+	if guildID == router.Direct {
+		slog.Info("Discord instance started for direct messages")
+	} else {
+		slog.Infof(`Discord instance started for guild id %v`, guildID)
 	}
 
-	canonicalCommand := getCanonicalCommand(command, commandAliases)
-	if canonicalCommand == "" {
-		return
-	}
-
-	slog.Warn("Canonical command is ", canonicalCommand)
-	slog.Warn("Len of Canonical command is ", len(canonicalCommand))
-
-	switch canonicalCommand {
-	case "roll":
-		d.handleRollCommand(s, m, parameter)
-
-	default:
-		// Unknown command
-	}
+	guild.Track(guild.Data.clear)
+	return nil
 }
 
-// parseCommand parses the command and parameter from the Discord input based on the provided pattern.
-func parseCommand(content, pattern string) (string, string, error) {
-	// Convert both content and pattern to lowercase for case-insensitive comparison
-	content = strings.ToLower(content)
-	pattern = strings.ToLower(pattern)
-
-	if !strings.HasPrefix(content, pattern) {
-		return "", "", fmt.Errorf("Pattern not found")
-	}
-
-	content = content[len(pattern):] // Strip the pattern
-
-	words := strings.Fields(content) // Split by whitespace, handling multiple spaces
-	if len(words) == 0 {
-		return "", "", fmt.Errorf("No command found")
-	}
-
-	command := words[0]
-	parameter := ""
-	if len(words) > 1 {
-		parameter = strings.Join(words[1:], " ")
-		parameter = strings.TrimSpace(parameter)
-	}
-	return command, parameter, nil
+// Stop stops serving the guild and drops its pending rolls.
+func (d *Discord) Stop(guildID string) {
+	d.guilds.Stop(guildID)
 }
 
-func parseCommandAndParameter(content, pattern string) (string, string, error) {
-	if !strings.HasPrefix(content, pattern) {
-		return "", "", fmt.Errorf("pattern not found")
-	}
+// State returns the state of the Discord instance in the guild.
+func (d *Discord) State(guildID string) lifecycle.State {
+	return d.guilds.State(guildID)
+}
 
-	content = content[len(pattern):]
+// settings returns the settings of the guild, the defaults in direct messages.
+func (d *Discord) settings(guildID string) settings.Settings {
+	return d.router.GuildSettings(guildID)
+}
 
-	words := strings.Fields(content)
-	if len(words) == 0 {
-		return "", "", fmt.Errorf("no command found")
+// pending returns the pending rolls of the guild, or of direct messages when guildID is empty.
+// It reports false when the guild is not served.
+func (d *Discord) pending(guildID string) (*pendingRolls, bool) {
+	guild, ok := d.guilds.Get(router.Scope(guildID))
+	if !ok {
+		return nil, false
 	}
+	return guild.Data, true
+}
 
-	command := strings.ToLower(words[0])
-	parameter := ""
-	if len(words) > 1 {
-		parameter = strings.Join(words[1:], " ")
-		parameter = strings.TrimSpace(parameter)
+// commands returns the commands of the module.
+func (d *Discord) commands() []*router.Command {
+	return []*router.Command{
+		{
+			Name: "roll", Aliases: []string{"r"},
+			Args:        []router.Arg{{Name: "dice", Optional: true, Variadic: true}},
+			Category:    router.CategoryRolls,
+			Description: "Roll dice and add up the result, the default roll of the guild (1d20) without dice",
+			Examples: []string{
+				"roll 2d20", "roll 1d20 2d6 1d4", "roll 1d20+4 vs 15", "roll 1d20+7 dc25 pf2",
+				"roll 4dF+2", "roll d%", "roll 4d6kh3", "roll 2d20kl1", "roll 2d{loot}",
+			},
+			Handler: router.Handle(d.handleRollCommand),
+		},
+		{
+			Name:        "default",
+			Args:        []router.Arg{{Name: "dice", Optional: true}},
+			Category:    router.CategorySoloPlay,
+			Description: "Show or set your default roll (direct messages only)",
+			Examples:    []string{"default 2d6"},
+			Handler:     router.Handle(d.handleDefaultCommand),
+		},
+		{
+			Name: "vs", Aliases: []string{"versus"},
+			Args:        []router.Arg{{Name: "participants", Variadic: true}},
+			Category:    router.CategoryContests,
+			Description: "Opposed roll, the highest total wins. Participants click Roll or reply within 2 minutes",
+			Examples:    []string{"vs @alice 1d20+5 @bob 1d20+3"},
+			Handler:     router.Handle(d.handleVersusCommand),
+		},
+		{
+			Name:        "group",
+			Args:        []router.Arg{{Name: "dc"}, {Name: "dice"}, {Name: "participants", Variadic: true}},
+			Category:    router.CategoryContests,
+			Description: "Group check, passes if half or more succeed",
+			Examples:    []string{"group dc15 1d20+2 @a @b @c"},
+			Handler:     router.Handle(d.handleGroupCommand),
+		},
+		{
+			Name: "coc", Aliases: []string{"cthulhu"},
+			Args:        []router.Arg{{Name: "skill"}, {Name: "dice", Optional: true}},
+			Category:    router.CategoryGameSystems,
+			Description: "Call of Cthulhu skill roll with bonus or penalty dice",
+			Examples:    []string{"coc 45", "coc 45 bonus1", "coc 60 penalty 2"},
+			Handler:     router.Handle(d.handleCoCCommand),
+		},
+		{
+			Name: "move", Aliases: []string{"pbta"},
+			Args:        []router.Arg{{Name: "modifier", Optional: true, Variadic: true}},
+			Category:    router.CategoryGameSystems,
+			Description: "Powered by the Apocalypse move with 10+ / 7-9 / 6- bands, `move labels` renames the bands",
+			Examples:    []string{"move 2d6+2", "pbta +2 adv", "move labels Strong | Weak | Miss", "move labels reset"},
+			Permission:  discordgo.PermissionManageServer,
+			Restricted:  restrictLabels,
+			Handler:     router.HandleRaw(d.handleMoveCommand),
+		},
+		{
+			Name: "blades", Aliases: []string{"fitd"},
+			Args:        []router.Arg{{Name: "dice", Optional: true, Variadic: true}},
+			Category:    router.CategoryGameSystems,
+			Description: "Blades in the Dark action, resistance and fortune rolls",
+			Examples:    []string{"blades 2 risky standard", "blades resist 3", "blades fortune 1"},
+			Handler:     router.Handle(d.handleBladesCommand),
+		},
+		{
+			Name: "genesys", Aliases: []string{"sw", "narrative"},
+			Args:        []router.Arg{{Name: "pool", Variadic: true}},
+			Category:    router.CategoryGameSystems,
+			Description: "Genesys / Star Wars narrative dice",
+			Examples:    []string{"genesys 2a 1p 2d 1c 1b 1s"},
+			Handler:     router.Handle(d.handleGenesysCommand),
+		},
+		{
+			Name:        "define",
+			Args:        []router.Arg{{Name: "name", Optional: true}, {Name: "faces", Optional: true, Variadic: true}},
+			Category:    router.CategoryRolls,
+			Description: "Define a custom die, roll it with `roll 2d{name}`, or list them",
+			Examples:    []string{"define loot [copper, silver:2, gold]", "define"},
+			Permission:  discordgo.PermissionManageServer,
+			Restricted:  restrictDefine,
+			Handler:     router.HandleRaw(d.handleDefineCommand),
+		},
+		{
+			Name:        "undefine",
+			Args:        []router.Arg{{Name: "name"}},
+			Category:    router.CategoryRolls,
+			Description: "Remove a custom die",
+			Examples:    []string{"undefine loot"},
+			Permission:  discordgo.PermissionManageServer,
+			Handler:     router.Handle(d.handleUndefineCommand),
+		},
+		{
+			Name: "iron", Aliases: []string{"ironsworn", "starforged"},
+			Args:        []router.Arg{{Name: "roll", Optional: true, Variadic: true}},
+			Category:    router.CategorySoloPlay,
+			Description: "Ironsworn action and progress rolls, progress tracks and oracles",
+			Examples: []string{
+				"iron action 2 1", "iron progress vow", "iron track new vow dangerous", "iron track mark vow",
+				"iron oracle", "iron oracle theme", "iron oracle price",
+			},
+			Handler: router.Handle(d.handleIronCommand),
+		},
+		{
+			Name: "table", Aliases: []string{"tables"},
+			Args:        []router.Arg{{Name: "name", Optional: true, Variadic: true}},
+			Category:    router.CategoryRolls,
+			Description: "Random tables, upload one with a CSV or Markdown file attached",
+			Examples:    []string{"table upload encounters", "table encounters", "table show encounters", "table remove encounters"},
+			Permission:  discordgo.PermissionManageServer,
+			Restricted:  router.Subcommands("table", "upload", "add", "remove", "delete"),
+			Handler:     router.Handle(d.handleTableCommand),
+		},
+		{
+			Name: "oracle", Aliases: []string{"mythic"},
+			Args:        []router.Arg{{Name: "question", Optional: true, Variadic: true}},
+			Category:    router.CategorySoloPlay,
+			Description: "Mythic fate chart with the channel chaos factor, random events and meaning tables",
+			Examples:    []string{"oracle likely is the door locked?", "oracle chaos 6", "oracle chaos up", "oracle event", "oracle meaning"},
+			Handler:     router.HandleRaw(d.handleOracleCommand),
+		},
+		{
+			Name: "deck", Aliases: []string{"cards"},
+			Args:        []router.Arg{{Name: "action", Optional: true, Variadic: true}},
+			Category:    router.CategoryRolls,
+			Description: "The channel card deck: poker, jokers, tarot or custom cards, and Deadlands-style initiative",
+			Examples: []string{
+				"deck new poker", "deck new [Sun, Moon, Star]", "deck draw 3", "deck discard", "deck shuffle",
+				"deck deal @alice @bob:2 goblins",
+			},
+			Handler: router.HandleRaw(d.handleDeckCommand),
+		},
+		{
+			Name: "flip", Aliases: []string{"coin"},
+			Args:        []router.Arg{{Name: "count", Optional: true}},
+			Category:    router.CategoryRolls,
+			Description: "Flip coins",
+			Examples:    []string{"flip", "flip 3"},
+			Handler:     router.Handle(d.handleFlipCommand),
+		},
+		{
+			Name: "pick", Aliases: []string{"choose"},
+			Args:        []router.Arg{{Name: "items", Variadic: true}},
+			Category:    router.CategoryRolls,
+			Description: "Pick one of the items, `voice` stands for your voice channel members",
+			Examples:    []string{"pick tavern, dungeon, forest", "pick voice"},
+			Handler:     router.HandleRaw(d.handlePickCommand),
+		},
+		{
+			Name: "shuffle", Aliases: []string{"order"},
+			Args:        []router.Arg{{Name: "items", Variadic: true}},
+			Category:    router.CategoryRolls,
+			Description: "Put the items in a random order, e.g. a marching or speaking order",
+			Examples:    []string{"shuffle @a @b @c", "order voice"},
+			Handler:     router.HandleRaw(d.handleShuffleCommand),
+		},
+		{
+			Name:        "teams",
+			Args:        []router.Arg{{Name: "count"}, {Name: "members", Variadic: true}},
+			Category:    router.CategoryRolls,
+			Description: "Split the members into random teams",
+			Examples:    []string{"teams 2 @a @b @c @d", "teams 3 voice"},
+			Handler:     router.HandleRaw(d.handleTeamsCommand),
+		},
 	}
-	return command, parameter, nil
 }
 
-func getCanonicalCommand(alias string, commandAliases [][]string) string {
-	for _, aliases := range commandAliases {
-		for _, a := range aliases {
-			if a == alias {
-				return aliases[0]
-			}
-		}
-	}
-	return ""
+// listen handles replies to pending contest rolls before commands are dispatched.
+func (d *Discord) listen(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	return d.handlePendingReply(s, m)
 }
 
 func (d *Discord) changeAvatar(s *discordgo.Session) {
+	d.avatarMu.Lock()
+	defer d.avatarMu.Unlock()
+
 	if time.Since(d.lastChangeAvatarTime) < d.rateLimitDuration {
 		//slog.Info("Rate-limited. Skipping changeAvatar.")
 		return
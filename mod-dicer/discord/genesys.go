@@ -0,0 +1,71 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+// handleGenesysCommand rolls Genesys / Star Wars narrative dice, e.g. "genesys 2a 1p 2d 1c 1b".
+func (d *Discord) handleGenesysCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
+	d.changeAvatar(s)
+
+	pool, err := dice.ParseGenesysPool(param)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	result := dice.RollGenesys(d.roller.Source, pool)
+
+	s.ChannelMessageSendEmbed(m.ChannelID, renderGenesysResult(result, d.settings(m.GuildID).EmbedColor))
+}
+
+// renderGenesysResult builds the embed of a narrative dice roll showing every face and the net result.
+func renderGenesysResult(result *dice.GenesysResult, color int) *discordgo.MessageEmbed {
+	faces := make([]string, len(result.Rolls))
+	for i, roll := range result.Rolls {
+		faces[i] = dice.GenesysIcons[roll.Die.Name] + " " + roll.Face.Label
+	}
+
+	var net []string
+	addNet := func(count int, positive, negative string) {
+		switch {
+		case count > 0:
+			net = append(net, describeSymbol(count, positive))
+		case count < 0:
+			net = append(net, describeSymbol(-count, negative))
+		}
+	}
+	addNet(result.Success, dice.SymbolSuccess, dice.SymbolFailure)
+	addNet(result.Advantage, dice.SymbolAdvantage, dice.SymbolThreat)
+	addNet(result.Triumph, dice.SymbolTriumph, "")
+	addNet(result.Despair, dice.SymbolDespair, "")
+	addNet(result.Light, dice.SymbolLight, "")
+	addNet(result.Dark, dice.SymbolDark, "")
+
+	title := "❌ Failure"
+	if result.Succeeded() {
+		title = "✅ Success"
+	}
+
+	summary := strings.Join(net, "\n")
+	if summary == "" {
+		summary = "Everything cancelled out"
+	}
+
+	return embed.NewEmbed().
+		SetTitle(title).
+		SetDescription(strings.Join(faces, "\n")).
+		AddField("Net result", summary).
+		SetColor(color).MessageEmbed
+}
+
+// describeSymbol renders a symbol count, e.g. "2 ✳️ Success".
+func describeSymbol(count int, symbol string) string {
+	return fmt.Sprintf("%d %s %s", count, dice.GenesysIcons[symbol], capitalize(symbol))
+}
@@ -0,0 +1,307 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+const maxProgressTracks = 25
+
+// handleIronCommand handles the Ironsworn commands, e.g. "iron action 2 1", "iron progress vow",
+// "iron track new vow dangerous" or "iron oracle".
+func (d *Discord) handleIronCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
+	fields := strings.Fields(param)
+	if len(fields) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Error: use `iron action`, `iron progress`, `iron track` or `iron oracle`")
+		return
+	}
+
+	switch fields[0] {
+	case "action", "a":
+		d.handleIronAction(s, m, fields[1:])
+	case "progress", "p":
+		d.handleIronProgress(s, m, fields[1:])
+	case "track", "tracks", "t":
+		d.handleIronTrack(s, m, fields[1:])
+	case "oracle", "o":
+		d.handleIronOracle(s, m, fields[1:])
+	default:
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: unknown Ironsworn command %q", fields[0]))
+	}
+}
+
+// handleIronAction rolls an action roll, e.g. "iron action 2" or "iron action 3 1".
+func (d *Discord) handleIronAction(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	if len(fields) == 0 || len(fields) > 2 {
+		s.ChannelMessageSend(m.ChannelID, "Error: give the stat and optional adds, e.g. `iron action 2 1`")
+		return
+	}
+
+	values := make([]int, 2)
+	for i, field := range fields {
+		value, err := strconv.Atoi(strings.TrimPrefix(field, "+"))
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "Error: the stat and adds should be numbers, e.g. `iron action 2 1`")
+			return
+		}
+		values[i] = value
+	}
+
+	d.changeAvatar(s)
+
+	roll, err := dice.RollIronAction(d.roller.Source, values[0], values[1])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	embedMsg := renderIronRoll("Action", roll, d.settings(m.GuildID).EmbedColor).
+		AddField(fmt.Sprintf("%d + %d + %d", roll.Action, values[0], values[1]), "`action die + stat + adds`").MakeFieldInline()
+
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+}
+
+// handleIronProgress rolls a progress roll against a progress track of the user, e.g. "iron progress vow".
+func (d *Discord) handleIronProgress(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	name := strings.Join(fields, " ")
+	if name == "" {
+		s.ChannelMessageSend(m.ChannelID, "Error: give the name of the progress track, e.g. `iron progress vow`")
+		return
+	}
+
+	track, ok := d.progressTrack(s, m, name)
+	if !ok {
+		return
+	}
+
+	d.changeAvatar(s)
+
+	roll, err := dice.RollIronProgress(d.roller.Source, track.Ticks/dice.IronBoxTicks)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	embedMsg := renderIronRoll("Progress", roll, d.settings(m.GuildID).EmbedColor).
+		AddField(dice.IronProgressBar(track.Ticks), fmt.Sprintf("`%s, %s`", track.Name, track.Rank)).MakeFieldInline()
+
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+}
+
+// handleIronTrack lists, creates, marks or clears progress tracks, e.g. "iron track new reach the keep dangerous",
+// "iron track mark reach the keep 2" or "iron track clear reach the keep".
+func (d *Discord) handleIronTrack(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	if len(fields) == 0 {
+		d.listProgressTracks(s, m)
+		return
+	}
+
+	action, fields := fields[0], fields[1:]
+	switch action {
+	case "new", "add":
+		if len(fields) < 2 {
+			s.ChannelMessageSend(m.ChannelID, "Error: give the name and rank of the track, e.g. `iron track new reach the keep dangerous`")
+			return
+		}
+
+		rank := fields[len(fields)-1]
+		if _, err := dice.IronTicksPerMark(rank); err != nil {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+			return
+		}
+
+		tracks, err := db.GetProgressTracks(m.Author.ID)
+		if err != nil {
+			slog.Errorf("Error loading progress tracks: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "Error saving the progress track")
+			return
+		}
+		if len(tracks) >= maxProgressTracks {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: up to %d progress tracks can be kept, clear some first", maxProgressTracks))
+			return
+		}
+
+		track := db.ProgressTrack{UserID: m.Author.ID, Name: strings.Join(fields[:len(fields)-1], " "), Rank: rank}
+		existing, err := db.GetProgressTrack(track.UserID, track.Name)
+		if err != nil {
+			slog.Errorf("Error loading progress track: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "Error saving the progress track")
+			return
+		}
+		if existing != nil {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: progress track `%s` already exists, clear it first", track.Name))
+			return
+		}
+
+		if !d.saveProgressTrack(s, m, track) {
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Progress track `%s` (%s) started", track.Name, track.Rank))
+
+	case "mark":
+		times := 1
+		if len(fields) > 1 {
+			if n, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+				times = n
+				fields = fields[:len(fields)-1]
+			}
+		}
+		if times < 1 || times > dice.MaxIronScore {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: progress can be marked 1 to %d times at once", dice.MaxIronScore))
+			return
+		}
+
+		track, ok := d.progressTrack(s, m, strings.Join(fields, " "))
+		if !ok {
+			return
+		}
+
+		ticks, err := dice.IronTicksPerMark(track.Rank)
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+			return
+		}
+		track.Ticks = min(track.Ticks+ticks*times, dice.IronTrackTicks)
+		if !d.saveProgressTrack(s, m, *track) {
+			return
+		}
+
+		embedMsg := embed.NewEmbed().
+			SetTitle(fmt.Sprintf("Progress marked on %s", track.Name)).
+			SetDescription(dice.IronProgressBar(track.Ticks)).
+			SetFooter(fmt.Sprintf("%s · progress score %d", track.Rank, track.Ticks/dice.IronBoxTicks)).
+			SetColor(d.settings(m.GuildID).EmbedColor).MessageEmbed
+		s.ChannelMessageSendEmbed(m.ChannelID, embedMsg)
+
+	case "clear", "remove", "delete":
+		name := strings.Join(fields, " ")
+		deleted, err := db.DeleteProgressTrack(m.Author.ID, name)
+		if err != nil {
+			slog.Errorf("Error deleting progress track: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "Error clearing the progress track")
+			return
+		}
+		if !deleted {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: no progress track named `%s`", name))
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Progress track `%s` cleared", name))
+
+	default:
+		s.ChannelMessageSend(m.ChannelID, "Error: use `iron track`, `iron track new`, `iron track mark` or `iron track clear`")
+	}
+}
+
+// handleIronOracle rolls on an oracle table, e.g. "iron oracle", "iron oracle theme" or "iron oracle price".
+func (d *Discord) handleIronOracle(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	table := "action"
+	if len(fields) > 0 {
+		table = fields[0]
+	}
+
+	d.changeAvatar(s)
+
+	embedMsg := embed.NewEmbed().SetColor(d.settings(m.GuildID).EmbedColor)
+	switch table {
+	case "action":
+		roll, action := dice.IronOracle(d.roller.Source, dice.IronActionOracle)
+		_, theme := dice.IronOracle(d.roller.Source, dice.IronThemeOracle)
+		embedMsg.SetTitle(fmt.Sprintf("🔮 %s / %s", action, theme)).
+			SetFooter(fmt.Sprintf("Action / Theme oracle, action roll %d", roll))
+	case "theme":
+		roll, theme := dice.IronOracle(d.roller.Source, dice.IronThemeOracle)
+		embedMsg.SetTitle(fmt.Sprintf("🔮 %s", theme)).
+			SetFooter(fmt.Sprintf("Theme oracle (%d)", roll))
+	case "price", "pay":
+		roll, price := dice.IronPayThePrice(d.roller.Source)
+		embedMsg.SetTitle("💀 Pay the Price").
+			SetDescription(price).
+			SetFooter(fmt.Sprintf("Pay the Price (%d)", roll))
+	default:
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: unknown oracle %q, use action, theme or price", table))
+		return
+	}
+
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+}
+
+// listProgressTracks shows the progress tracks of the user.
+func (d *Discord) listProgressTracks(s *discordgo.Session, m *discordgo.MessageCreate) {
+	tracks, err := db.GetProgressTracks(m.Author.ID)
+	if err != nil {
+		slog.Errorf("Error loading progress tracks: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error loading progress tracks")
+		return
+	}
+
+	if len(tracks) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "No progress tracks yet, start one with `iron track new <name> <rank>`")
+		return
+	}
+
+	embedMsg := embed.NewEmbed().SetTitle("Progress tracks").SetColor(d.settings(m.GuildID).EmbedColor)
+	for _, track := range tracks {
+		embedMsg.AddField(track.Name, fmt.Sprintf("%s `%s`", dice.IronProgressBar(track.Ticks), track.Rank))
+	}
+
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+}
+
+// progressTrack loads a progress track of the user, replying with an error when it cannot be found.
+func (d *Discord) progressTrack(s *discordgo.Session, m *discordgo.MessageCreate, name string) (*db.ProgressTrack, bool) {
+	track, err := db.GetProgressTrack(m.Author.ID, name)
+	if err != nil {
+		slog.Errorf("Error loading progress track: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error loading the progress track")
+		return nil, false
+	}
+	if track == nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: no progress track named `%s`, see `iron track`", name))
+		return nil, false
+	}
+	return track, true
+}
+
+// saveProgressTrack saves a progress track, replying with an error when it fails.
+func (d *Discord) saveProgressTrack(s *discordgo.Session, m *discordgo.MessageCreate, track db.ProgressTrack) bool {
+	if err := db.SaveProgressTrack(track); err != nil {
+		slog.Errorf("Error saving progress track: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error saving the progress track")
+		return false
+	}
+	return true
+}
+
+// renderIronRoll builds the embed of an action or progress roll with its challenge dice.
+func renderIronRoll(kind string, roll *dice.IronRoll, color int) *embed.Embed {
+	icons := map[dice.IronOutcome]string{dice.IronStrongHit: "🟢", dice.IronWeakHit: "🟡", dice.IronMiss: "🔴"}
+
+	title := fmt.Sprintf("%s %s (%d)", icons[roll.Outcome], roll.Outcome, roll.Score)
+	embedMsg := embed.NewEmbed().
+		SetTitle(title).
+		AddField(fmt.Sprintf("%d, %d", roll.Challenge[0], roll.Challenge[1]), "`challenge dice`").MakeFieldInline().
+		SetColor(color)
+
+	footer := kind + " roll"
+	if roll.Match {
+		switch roll.Outcome {
+		case dice.IronStrongHit:
+			footer += " · Match: an opportunity or unexpected advantage"
+		case dice.IronMiss:
+			footer += " · Match: a dire threat or complication"
+		default:
+			footer += " · Match"
+		}
+	}
+	embedMsg.SetFooter(footer)
+
+	return embedMsg
+}
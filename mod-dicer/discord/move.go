@@ -0,0 +1,133 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+// restrictLabels restricts changing the move labels of the guild, moves and showing the labels are open to everyone.
+func restrictLabels(param string) string {
+	fields := strings.Fields(param)
+	if len(fields) < 2 || fields[0] != "labels" {
+		return ""
+	}
+	return "move labels"
+}
+
+// handleMoveCommand resolves a Powered by the Apocalypse move, e.g. "move 2d6+2", "pbta +1 adv" or "move labels ...".
+func (d *Discord) handleMoveCommand(s *discordgo.Session, m *discordgo.MessageCreate, raw string) {
+	fields := strings.Fields(strings.ToLower(raw))
+	if len(fields) > 0 && fields[0] == "labels" {
+		d.handleMoveLabelsCommand(s, m, strings.Join(strings.Fields(raw)[1:], " "))
+		return
+	}
+
+	d.changeAvatar(s)
+
+	advantage := 0
+	var modifiers []string
+	for _, field := range fields {
+		switch field {
+		case "adv", "advantage":
+			advantage++
+		case "dis", "disadvantage":
+			advantage--
+		default:
+			modifiers = append(modifiers, field)
+		}
+	}
+
+	expr, err := dice.MoveExpression(strings.Join(modifiers, " "), advantage)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: invalid input. %v", err))
+		return
+	}
+
+	result := d.roller.Roll(expr)
+	labels := d.moveLabels(m)
+	band := dice.MoveBand(result.Total)
+
+	icons := map[dice.Band]string{dice.StrongHit: "🟢", dice.WeakHit: "🟡", dice.Miss: "🔴"}
+
+	embedMsg := renderRollResult(result, d.settings(m.GuildID).EmbedColor)
+	embedMsg.Title = fmt.Sprintf("%s %s (%d)", icons[band], labels.Label(band), result.Total)
+	embedMsg.Footer = &discordgo.MessageEmbedFooter{
+		Text: fmt.Sprintf("10+ %s · 7-9 %s · 6- %s", labels.StrongHit, labels.WeakHit, labels.Miss),
+	}
+
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embedMsg); err != nil {
+		slog.Errorf("Error sending move roll %v: %v", result.Expression, err)
+	}
+}
+
+// handleMoveLabelsCommand shows, changes or resets the move band labels, e.g. "move labels Strong | Weak | Miss".
+func (d *Discord) handleMoveLabelsCommand(s *discordgo.Session, m *discordgo.MessageCreate, raw string) {
+	switch {
+	case raw == "":
+		labels := d.moveLabels(m)
+		embedMsg := embed.NewEmbed().
+			SetTitle("Move labels").
+			AddField(labels.StrongHit, "`10+`").MakeFieldInline().
+			AddField(labels.WeakHit, "`7-9`").MakeFieldInline().
+			AddField(labels.Miss, "`6-`").MakeFieldInline().
+			SetColor(d.settings(m.GuildID).EmbedColor).MessageEmbed
+		s.ChannelMessageSendEmbed(m.ChannelID, embedMsg)
+
+	case strings.EqualFold(raw, "reset"):
+		if err := db.DeleteMoveLabels(scopeOf(m.GuildID, m.Author.ID)); err != nil {
+			slog.Errorf("Error resetting move labels: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "Error resetting move labels")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, "Move labels reset to defaults")
+
+	default:
+		parts := strings.Split(raw, "|")
+		if len(parts) != 3 {
+			s.ChannelMessageSend(m.ChannelID, "Error: give three labels separated by `|`, e.g. `move labels Strong hit | Weak hit | Miss`")
+			return
+		}
+
+		labels := db.MoveLabels{
+			ScopeID:   scopeOf(m.GuildID, m.Author.ID),
+			StrongHit: strings.TrimSpace(parts[0]),
+			WeakHit:   strings.TrimSpace(parts[1]),
+			Miss:      strings.TrimSpace(parts[2]),
+		}
+		if labels.StrongHit == "" || labels.WeakHit == "" || labels.Miss == "" {
+			s.ChannelMessageSend(m.ChannelID, "Error: labels can't be empty")
+			return
+		}
+
+		if err := db.SaveMoveLabels(labels); err != nil {
+			slog.Errorf("Error saving move labels: %v", err)
+			s.ChannelMessageSend(m.ChannelID, "Error saving move labels")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Move labels set to **%s** / **%s** / **%s**", labels.StrongHit, labels.WeakHit, labels.Miss))
+	}
+}
+
+// moveLabels returns the move band labels of the guild or user, falling back to the defaults.
+func (d *Discord) moveLabels(m *discordgo.MessageCreate) dice.BandLabels {
+	stored, err := db.GetMoveLabels(scopeOf(m.GuildID, m.Author.ID))
+	if err != nil {
+		slog.Errorf("Error loading move labels: %v", err)
+	}
+	if stored == nil {
+		return dice.DefaultBandLabels
+	}
+
+	return dice.BandLabels{
+		StrongHit: stored.StrongHit,
+		WeakHit:   stored.WeakHit,
+		Miss:      stored.Miss,
+	}
+}
@@ -0,0 +1,130 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+// handleOracleCommand asks the Mythic fate chart a yes/no question, e.g. "oracle likely is the door locked?",
+// or handles "oracle chaos", "oracle event" and "oracle meaning".
+func (d *Discord) handleOracleCommand(s *discordgo.Session, m *discordgo.MessageCreate, raw string) {
+	fields := strings.Fields(strings.ToLower(raw))
+	if len(fields) > 0 {
+		switch fields[0] {
+		case "chaos", "cf":
+			d.handleChaosCommand(s, m, fields[1:])
+			return
+		case "event":
+			d.changeAvatar(s)
+			embedMsg := embed.NewEmbed().SetTitle("⚡ Random event").SetColor(d.settings(m.GuildID).EmbedColor)
+			addRandomEvent(embedMsg, dice.RollRandomEvent(d.roller.Source))
+			s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+			return
+		case "meaning":
+			d.changeAvatar(s)
+			action, subject := dice.RollMeaning(d.roller.Source)
+			embedMsg := embed.NewEmbed().
+				SetTitle(fmt.Sprintf("🔮 %s / %s", action, subject)).
+				SetFooter("Meaning tables: action / subject").
+				SetColor(d.settings(m.GuildID).EmbedColor).MessageEmbed
+			s.ChannelMessageSendEmbed(m.ChannelID, embedMsg)
+			return
+		}
+	}
+
+	d.changeAvatar(s)
+
+	// keep the letter case of the question
+	odds, question, _ := dice.ParseOdds(raw)
+
+	chaos := d.chaosFactor(m.ChannelID)
+	q, err := dice.AskFateChart(d.roller.Source, odds, chaos)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	icons := map[dice.Answer]string{dice.ExceptionalYes: "🌟", dice.Yes: "🟢", dice.No: "🔴", dice.ExceptionalNo: "💀"}
+	_, yes, _ := dice.FateThresholds(q.Odds, q.Chaos)
+
+	embedMsg := embed.NewEmbed().
+		SetTitle(fmt.Sprintf("%s %s", icons[q.Answer], q.Answer)).
+		AddField(fmt.Sprintf("%d", q.Roll), fmt.Sprintf("`d100, yes up to %d`", yes)).MakeFieldInline().
+		AddField(capitalize(q.Odds.String()), "`odds`").MakeFieldInline().
+		AddField(strconv.Itoa(q.Chaos), "`chaos factor`").MakeFieldInline().
+		SetColor(d.settings(m.GuildID).EmbedColor)
+	if question != "" {
+		embedMsg.SetDescription("*" + question + "*")
+	}
+	if q.Event != nil {
+		addRandomEvent(embedMsg, q.Event)
+	}
+
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+}
+
+// handleChaosCommand shows or changes the chaos factor of the channel, e.g. "oracle chaos 6" or "oracle chaos up".
+func (d *Discord) handleChaosCommand(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	chaos := d.chaosFactor(m.ChannelID)
+	if len(fields) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Chaos factor is **%d**", chaos))
+		return
+	}
+
+	switch fields[0] {
+	case "up", "+":
+		chaos++
+	case "down", "-":
+		chaos--
+	case "reset":
+		chaos = dice.DefaultChaos
+	default:
+		value, err := strconv.Atoi(fields[0])
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "Error: give the chaos factor, `up`, `down` or `reset`, e.g. `oracle chaos 6`")
+			return
+		}
+		chaos = value
+	}
+
+	if chaos < dice.MinChaos || chaos > dice.MaxChaos {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: chaos factor should be between %d and %d", dice.MinChaos, dice.MaxChaos))
+		return
+	}
+
+	if err := db.SaveChaosFactor(db.ChaosFactor{ChannelID: m.ChannelID, Value: chaos}); err != nil {
+		slog.Errorf("Error saving chaos factor: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error saving the chaos factor")
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Chaos factor set to **%d**", chaos))
+}
+
+// chaosFactor returns the chaos factor of the channel, or the default one.
+func (d *Discord) chaosFactor(channelID string) int {
+	chaos, err := db.GetChaosFactor(channelID)
+	if err != nil {
+		slog.Errorf("Error loading chaos factor: %v", err)
+	}
+	if chaos == nil {
+		return dice.DefaultChaos
+	}
+	return chaos.Value
+}
+
+// addRandomEvent adds the focus and meaning of a random event to the embed.
+func addRandomEvent(embedMsg *embed.Embed, event *dice.RandomEvent) {
+	embedMsg.AddField(
+		fmt.Sprintf("⚡ %s (%d)", event.Focus, event.FocusRoll),
+		fmt.Sprintf("%s / %s", event.Action, event.Subject),
+	)
+}
@@ -0,0 +1,209 @@
+package discord
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+const pendingRollTimeout = 2 * time.Minute
+
+type pendingKind int
+
+const (
+	opposedRoll pendingKind = iota
+	groupRoll
+)
+
+type pendingState int
+
+const (
+	pendingCollecting pendingState = iota
+	pendingResolved
+	pendingExpired
+)
+
+// participant is a user taking part in a pending roll.
+type participant struct {
+	UserID     string
+	Expression string
+	Result     *dice.Result
+	// rolling is set while the participant's expression is evaluated, so they can't roll twice meanwhile.
+	rolling bool
+}
+
+// pendingRoll is an opposed roll or a group check waiting for its participants to roll.
+type pendingRoll struct {
+	ID           string
+	MessageID    string
+	ChannelID    string
+	Color        int
+	Kind         pendingKind
+	DC           int
+	Participants []*participant
+	State        pendingState
+	Deadline     time.Time
+	timer        *time.Timer
+}
+
+// participant returns the participant with the given user ID or nil.
+func (p *pendingRoll) participant(userID string) *participant {
+	for _, part := range p.Participants {
+		if part.UserID == userID {
+			return part
+		}
+	}
+	return nil
+}
+
+// snapshot returns a copy of the pending roll that does not change when participants roll later.
+func (p *pendingRoll) snapshot() *pendingRoll {
+	c := *p
+	c.Participants = make([]*participant, len(p.Participants))
+	for i, part := range p.Participants {
+		partCopy := *part
+		c.Participants[i] = &partCopy
+	}
+	return &c
+}
+
+// allRolled reports whether every participant has rolled.
+func (p *pendingRoll) allRolled() bool {
+	for _, part := range p.Participants {
+		if part.Result == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// pendingRolls holds the pending rolls of a Discord instance.
+type pendingRolls struct {
+	mu    sync.Mutex
+	rolls map[string]*pendingRoll
+}
+
+func newPendingRolls() *pendingRolls {
+	return &pendingRolls{rolls: make(map[string]*pendingRoll)}
+}
+
+// add stores the pending roll and calls onExpire once its timeout passes without everyone rolling.
+func (pr *pendingRolls) add(p *pendingRoll, timeout time.Duration, onExpire func(*pendingRoll)) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	p.State = pendingCollecting
+	p.Deadline = time.Now().Add(timeout)
+	p.timer = time.AfterFunc(timeout, func() {
+		if pr.expire(p.ID) {
+			onExpire(p)
+		}
+	})
+	pr.rolls[p.ID] = p
+}
+
+// attach links the announcement message to the pending roll so replies can be matched to it.
+func (pr *pendingRolls) attach(id, messageID string) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	if p, ok := pr.rolls[id]; ok {
+		p.MessageID = messageID
+	}
+}
+
+// findByMessage returns the ID of the pending roll announced in the given message.
+func (pr *pendingRolls) findByMessage(messageID string) (string, bool) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	for id, p := range pr.rolls {
+		if p.MessageID == messageID {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// record rolls the participant's expression and reports whether the pending roll is now resolved.
+// The returned pending roll is a snapshot that is safe to read without holding the lock.
+// The expression is evaluated without the lock, custom dice may need the database.
+func (pr *pendingRolls) record(id, userID string, roller *dice.Roller) (*pendingRoll, bool, error) {
+	p, part, err := pr.claim(id, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	result, err := roller.Evaluate(part.Expression)
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	part.rolling = false
+	if err != nil {
+		return nil, false, err
+	}
+	if p.State != pendingCollecting {
+		return nil, false, fmt.Errorf("this roll is already over")
+	}
+	part.Result = result
+
+	if !p.allRolled() {
+		return p.snapshot(), false, nil
+	}
+
+	p.State = pendingResolved
+	p.timer.Stop()
+	delete(pr.rolls, id)
+	return p.snapshot(), true, nil
+}
+
+// claim marks the participant of the pending roll as rolling.
+func (pr *pendingRolls) claim(id, userID string) (*pendingRoll, *participant, error) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	p, ok := pr.rolls[id]
+	if !ok || p.State != pendingCollecting {
+		return nil, nil, fmt.Errorf("this roll is already over")
+	}
+
+	part := p.participant(userID)
+	if part == nil {
+		return nil, nil, fmt.Errorf("you are not taking part in this roll")
+	}
+	if part.Result != nil || part.rolling {
+		return nil, nil, fmt.Errorf("you have already rolled")
+	}
+	part.rolling = true
+	return p, part, nil
+}
+
+// expire marks the pending roll as expired and removes it, reporting whether it was still collecting.
+func (pr *pendingRolls) expire(id string) bool {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	p, ok := pr.rolls[id]
+	if !ok || p.State != pendingCollecting {
+		return false
+	}
+
+	p.State = pendingExpired
+	delete(pr.rolls, id)
+	return true
+}
+
+// clear stops all timers and drops every pending roll.
+func (pr *pendingRolls) clear() {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	for id, p := range pr.rolls {
+		p.timer.Stop()
+		p.State = pendingExpired
+		delete(pr.rolls, id)
+	}
+}
@@ -1,18 +1,15 @@
 package discord
 
 import (
-	"crypto/rand"
 	"fmt"
 	"math"
-	"math/big"
-	"regexp"
-	"strconv"
 	"strings"
 
 	embed "github.com/Clinet/discordgo-embed"
 	"github.com/bwmarrin/discordgo"
 	"github.com/gookit/slog"
 
+	"github.com/keshon/dice-roller/mod-dicer/dice"
 	"github.com/keshon/dice-roller/mod-dicer/utils"
 )
 
@@ -21,150 +18,146 @@ func (d *Discord) handleRollCommand(s *discordgo.Session, m *discordgo.MessageCr
 	d.changeAvatar(s)
 
 	if param == "" {
-		param = "1d20"
+		param = d.defaultRoll(m)
 	}
 
-	tokens := tokenize(param)
-
-	if len(tokens) > 10 {
-		s.ChannelMessageSend(m.ChannelID, "Error: you can roll up to 10 dice in a single command.")
-		return
-	}
-
-	totalResult, detailedResults, hasSingleMultiplier, processedKeys, err := processDiceTokens(tokens)
+	result, err := d.scopedRoller(scopeOf(m.GuildID, m.Author.ID)).Evaluate(param)
 	if err != nil {
-		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: invalid input. %v", err))
 		return
 	}
 
-	embedMsg := embed.NewEmbed().
-		SetTitle(fmt.Sprintf("= %d", totalResult)).
-		SetColor(0x9f00d4)
-
-	for _, key := range processedKeys {
-		results := detailedResults[key]
+	slog.Infof("Rolled %v: %v", result.Expression, describeRolls(result))
 
-		if len(processedKeys) == 1 && hasSingleMultiplier {
-			embedMsg.AddField("", "`"+key+"`").MakeFieldInline()
-		} else {
-			embedMsg.AddField(fmt.Sprintf("(%s)\n", strings.Trim(strings.Join(strings.Fields(fmt.Sprint(results)), " + "), "[]")), "`"+key+"`").MakeFieldInline()
-		}
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, renderRollResult(result, d.settings(m.GuildID).EmbedColor)); err != nil {
+		slog.Errorf("Error sending roll %v: %v", result.Expression, err)
 	}
-
-	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
 }
 
-// processDiceTokens processes the tokens and returns the total result, detailed results, and processed keys.
-func processDiceTokens(tokens []string) (int, map[string][]int, bool, []string, error) {
-	totalResult := 0
-	detailedResults := make(map[string][]int)
-	processedKeys := []string{}
-	hasSingleMultiplier := true
-
-	for i, token := range tokens {
-		multiplier, diceSides, err := parseToken(token)
-		if err != nil {
-			return 0, nil, false, nil, fmt.Errorf("invalid input. %v", err)
-		}
-
-		slog.Infof("[%v] work with token is %v:", i, token)
-		slog.Infof("[%v] ..multiplier for token %v is %v: ", i, token, multiplier)
-		slog.Infof("[%v] ..diceSides for token %v is %v: ", i, token, diceSides)
-
-		if diceSides <= 0 {
-			return 0, nil, false, nil, fmt.Errorf("Invalid input. Dice sides must be greater than zero.")
-		}
-
-		results := make([]int, utils.AbsInt(multiplier))
-		for j := 0; j < multiplier; j++ {
-			rollResult, err := secureRandomInt(diceSides)
-
-			if err != nil {
-				slog.Errorf("Error generating secure random number: %v", err)
-				return 0, nil, false, nil, err
-			}
+// Discord refuses embeds whose title or field names are longer than 256 characters, or field values longer than 1024.
+const (
+	maxEmbedTitle = 256
+	maxFieldValue = 1024
+)
 
-			results[j] = rollResult
-			totalResult += rollResult
-		}
+// renderRollResult builds the embed of an evaluated expression.
+func renderRollResult(result *dice.Result, color int) *discordgo.MessageEmbed {
+	title := fmt.Sprintf("= %d", result.Total)
+	if result.HasFate() {
+		title = "= " + dice.FateLadder(result.Total)
+	}
 
-		key := fmt.Sprintf("%dd%d", multiplier, diceSides)
-		detailedResults[key] = results
-		processedKeys = append(processedKeys, key)
+	tally := result.Tally()
+	if len(tally) > 0 && onlySymbols(result) {
+		title = "= " + describeTally(tally)
+		tally = nil
+	}
 
-		if multiplier > 1 {
-			hasSingleMultiplier = false
+	embedMsg := embed.NewEmbed().
+		SetTitle(truncate(title, maxEmbedTitle)).
+		SetColor(color)
+
+	singleDie := len(result.Terms) == 1 && result.DiceCount() == 1
+
+	for _, term := range result.Terms {
+		switch {
+		case !term.IsDice():
+			embedMsg.AddField(fmt.Sprintf("%+d", term.Total()), "`modifier`").MakeFieldInline()
+		case singleDie:
+			embedMsg.AddField("", "`"+term.String()+"`").MakeFieldInline()
+		case term.Custom != "":
+			// custom faces are labels up to 50 characters long, too long for a field name
+			embedMsg.AddField(notation(term), truncate(joinFaces(term), maxFieldValue)).MakeFieldInline()
+		default:
+			embedMsg.AddField(fmt.Sprintf("(%s)\n", joinFaces(term)), "`"+notation(term)+"`").MakeFieldInline()
 		}
-
-		slog.Infof("[%v] ..rolled values are %v\n", i, results)
 	}
 
-	return totalResult, detailedResults, hasSingleMultiplier, processedKeys, nil
-}
-
-// secureRandomInt generates a secure random integer between 1 and max (inclusive).
-func secureRandomInt(max int) (int, error) {
-	if max <= 0 {
-		return 0, nil
+	if len(tally) > 0 {
+		embedMsg.AddField("Tally", truncate(describeTally(tally), maxFieldValue))
 	}
 
-	// Generate a random number in the range [0, max-1]
-	randomNum, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
-	if err != nil {
-		return 0, err
+	if result.Check != nil {
+		embedMsg.AddField(describeCheck(result.Check), "`"+result.Check.String()+"`")
 	}
 
-	// Add 1 to make the range [1, max]
-	return int(randomNum.Int64()) + 1, nil
+	return embedMsg.MessageEmbed
 }
 
-// getRandomDeviation generates a random deviation based on initial velocity, angular velocity, air resistance, mass, and shape factor.
-func getRandomDeviation(initialVelocity, angularVelocity, airResistance, mass, shapeFactor float64) float64 {
-	// Simulate the effect of initial velocity, angular velocity, air resistance, mass, and shape factor on deviation
-	combinedParameter := (math.Pow(initialVelocity, 0.8) * math.Pow(angularVelocity, 0.5) / airResistance) / (mass * shapeFactor)
-	return combinedParameter - 0.5
+// notation returns the notation of a dice term with its sign, e.g. "-1d4".
+func notation(term dice.Term) string {
+	if term.Sign < 0 {
+		return "-" + term.String()
+	}
+	return term.String()
 }
 
-// tokenize breaks down the input into tokens along with signs.
-func tokenize(input string) []string {
-	delimiters := `[^0-9d]+`
-	r := regexp.MustCompile(delimiters)
-	tokens := r.Split(input, -1)
-	return tokens
+// truncate shortens the text to limit characters, ending it with an ellipsis when it was longer.
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
 }
 
-// parseToken extracts multiplier and dice sides from a token.
-func parseToken(token string) (int, int, error) {
-	parts := strings.Split(token, "d")
-	if len(parts) != 2 {
-		return 0, 0, fmt.Errorf("Invalid token format")
+// joinFaces lists the rolled faces of a term, adding up numbers and spacing out symbols.
+func joinFaces(term dice.Term) string {
+	switch {
+	case term.Fate:
+		return strings.Join(term.Faces(), " ")
+	case term.Custom != "":
+		return strings.Join(term.Faces(), ", ")
 	}
+	return strings.Join(term.Faces(), " + ")
+}
 
-	// Parse multiplier
-	multiplier := 1
-	if parts[0] != "" {
-		var err error
-		multiplier, err = strconv.Atoi(parts[0])
-		if err != nil {
-			return 0, 0, fmt.Errorf("Invalid multiplier in token: %s", token)
+// onlySymbols reports whether the result consists only of symbolic custom faces, so its total means nothing.
+func onlySymbols(result *dice.Result) bool {
+	for _, term := range result.Terms {
+		if term.Custom == "" {
+			return false
 		}
-
-		// Check for a reasonable range for multiplier (adjust the limit as needed)
-		if multiplier <= 0 || multiplier > 10 {
-			return 0, 0, fmt.Errorf("Multiplier should be between 1 and 10")
+		for _, roll := range term.FaceRolls {
+			if roll.Face.Symbols == nil {
+				return false
+			}
 		}
 	}
+	return true
+}
 
-	// Parse dice sides
-	diceSides, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return 0, 0, fmt.Errorf("Invalid dice sides in token: %s", token)
+// describeTally renders counted symbols, e.g. "2× copper, 1× gold".
+func describeTally(tally []dice.SymbolCount) string {
+	parts := make([]string, len(tally))
+	for i, count := range tally {
+		parts[i] = fmt.Sprintf("%d× %s", count.Count, count.Symbol)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeCheck reports the degree of success and the margin of a check.
+func describeCheck(check *dice.CheckResult) string {
+	icon := "❌"
+	if check.Degree.IsSuccess() {
+		icon = "✅"
 	}
 
-	// Check for a reasonable range for dice sides (adjust the limit as needed)
-	if diceSides <= 0 || diceSides > 100 {
-		return 0, 0, fmt.Errorf("Dice sides should be between 1 and 100")
+	description := fmt.Sprintf("%s %s by %d", icon, check.Degree, utils.AbsInt(check.Margin))
+
+	switch {
+	case check.Degrees && check.Natural == 20:
+		description += " (natural 20)"
+	case check.Degrees && check.Natural == 1:
+		description += " (natural 1)"
 	}
 
-	return multiplier, diceSides, nil
+	return description
+}
+
+// getRandomDeviation generates a random deviation based on initial velocity, angular velocity, air resistance, mass, and shape factor.
+func getRandomDeviation(initialVelocity, angularVelocity, airResistance, mass, shapeFactor float64) float64 {
+	// Simulate the effect of initial velocity, angular velocity, air resistance, mass, and shape factor on deviation
+	combinedParameter := (math.Pow(initialVelocity, 0.8) * math.Pow(angularVelocity, 0.5) / airResistance) / (mass * shapeFactor)
+	return combinedParameter - 0.5
 }
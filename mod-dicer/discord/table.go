@@ -0,0 +1,237 @@
+package discord
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+	"github.com/keshon/dice-roller/mod-dicer/utils"
+)
+
+const (
+	maxRandomTables  = 50
+	maxTableFileSize = 64 << 10
+
+	// maxTableText and maxTableRolls keep a table embed, with its title, under the 6000 characters Discord allows.
+	maxTableText  = 4000
+	maxTableRolls = 1000
+)
+
+// handleTableCommand rolls on a random table, e.g. "table encounters", or manages the tables with
+// "table upload", "table show" and "table remove".
+func (d *Discord) handleTableCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
+	fields := strings.Fields(param)
+	if len(fields) == 0 {
+		d.listRandomTables(s, m)
+		return
+	}
+
+	switch fields[0] {
+	case "upload", "add":
+		d.handleTableUpload(s, m, fields[1:])
+	case "show":
+		d.handleTableShow(s, m, fields[1:])
+	case "remove", "delete":
+		d.handleTableRemove(s, m, fields[1:])
+	case "roll":
+		d.rollRandomTable(s, m, fields[1:])
+	default:
+		d.rollRandomTable(s, m, fields)
+	}
+}
+
+// handleTableUpload stores the table attached to the message, named after the file unless a name is given.
+func (d *Discord) handleTableUpload(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	if len(m.Attachments) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Error: attach a CSV or Markdown file with `1-3 | Goblins` rows, e.g. `table upload encounters`")
+		return
+	}
+
+	attachment := m.Attachments[0]
+	if attachment.Size > maxTableFileSize {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: tables can be up to %d KB", maxTableFileSize>>10))
+		return
+	}
+
+	name := strings.TrimSuffix(attachment.Filename, filepath.Ext(attachment.Filename))
+	name = strings.ReplaceAll(strings.ToLower(name), " ", "-")
+	if len(fields) > 0 {
+		name = fields[0]
+	}
+
+	content, err := utils.DownloadFile(attachment.URL, maxTableFileSize)
+	if err != nil {
+		slog.Errorf("Error downloading table: %v", err)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	table, err := dice.ParseTable(name, string(content))
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	scopeID := scopeOf(m.GuildID, m.Author.ID)
+	existing, err := db.GetRandomTables(scopeID)
+	if err != nil {
+		slog.Errorf("Error loading random tables: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error saving the table")
+		return
+	}
+	if len(existing) >= maxRandomTables && !hasRandomTable(existing, table.Name) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: up to %d tables can be uploaded, `table remove` some first", maxRandomTables))
+		return
+	}
+
+	err = db.SaveRandomTable(db.RandomTable{ScopeID: scopeID, Name: table.Name, Rows: table.String()})
+	if err != nil {
+		slog.Errorf("Error saving random table: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error saving the table")
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Table `%s` saved with %d rows (d%d), roll it with `%stable %s`", table.Name, len(table.Rows), table.Die(), d.settings(m.GuildID).Prefix, table.Name))
+}
+
+// handleTableShow lists the rows of a table.
+func (d *Discord) handleTableShow(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	if len(fields) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Error: give the name of the table, e.g. `table show encounters`")
+		return
+	}
+
+	table, err := d.randomTable(scopeOf(m.GuildID, m.Author.ID), fields[0])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	embedMsg := embed.NewEmbed().
+		SetTitle(truncate(fmt.Sprintf("Table %s (d%d)", table.Name, table.Die()), maxEmbedTitle)).
+		SetDescription(truncate(table.String(), maxTableText)).
+		SetColor(d.settings(m.GuildID).EmbedColor).MessageEmbed
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embedMsg); err != nil {
+		slog.Errorf("Error sending table %v: %v", table.Name, err)
+	}
+}
+
+// handleTableRemove removes a table.
+func (d *Discord) handleTableRemove(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	if len(fields) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Error: give the name of the table, e.g. `table remove encounters`")
+		return
+	}
+
+	found, err := db.DeleteRandomTable(scopeOf(m.GuildID, m.Author.ID), fields[0])
+	if err != nil {
+		slog.Errorf("Error deleting random table: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error removing the table")
+		return
+	}
+
+	if !found {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Table `%s` is not uploaded", fields[0]))
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Table `%s` removed", fields[0]))
+}
+
+// rollRandomTable rolls on a table, following the tables it references and rolling the dice in its entries.
+func (d *Discord) rollRandomTable(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	if len(fields) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Error: give the name of the table, e.g. `table encounters`")
+		return
+	}
+
+	d.changeAvatar(s)
+
+	scopeID := scopeOf(m.GuildID, m.Author.ID)
+	roller := &dice.TableRoller{
+		Roller: d.scopedRoller(scopeID),
+		Tables: func(name string) (*dice.Table, error) {
+			return d.randomTable(scopeID, name)
+		},
+	}
+
+	result, err := roller.RollTable(fields[0])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	embedMsg := embed.NewEmbed().
+		SetTitle(truncate("🎲 "+capitalize(result.Table), maxEmbedTitle)).
+		SetDescription(truncate(result.Text, maxTableText)).
+		SetFooter(truncate(strings.Join(describeTableRolls(result), " · "), maxTableRolls)).
+		SetColor(d.settings(m.GuildID).EmbedColor).MessageEmbed
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embedMsg); err != nil {
+		slog.Errorf("Error sending table roll %v: %v", result.Table, err)
+	}
+}
+
+// listRandomTables shows the tables of the guild or user.
+func (d *Discord) listRandomTables(s *discordgo.Session, m *discordgo.MessageCreate) {
+	tables, err := db.GetRandomTables(scopeOf(m.GuildID, m.Author.ID))
+	if err != nil {
+		slog.Errorf("Error loading random tables: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error loading random tables")
+		return
+	}
+
+	if len(tables) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "No tables yet, attach a CSV or Markdown file to `table upload <name>`")
+		return
+	}
+
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = "`" + table.Name + "`"
+	}
+
+	embedMsg := embed.NewEmbed().
+		SetTitle("Random tables").
+		SetDescription(truncate(strings.Join(names, ", "), maxTableText)).
+		SetColor(d.settings(m.GuildID).EmbedColor).MessageEmbed
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embedMsg); err != nil {
+		slog.Errorf("Error sending random tables: %v", err)
+	}
+}
+
+// randomTable loads and parses a stored table.
+func (d *Discord) randomTable(scopeID, name string) (*dice.Table, error) {
+	stored, err := db.GetRandomTable(scopeID, strings.ToLower(name))
+	if err != nil {
+		slog.Errorf("Error loading random table: %v", err)
+		return nil, fmt.Errorf("can't load table %q", name)
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("table %q is not uploaded, see `table`", name)
+	}
+	return dice.ParseTable(stored.Name, stored.Rows)
+}
+
+// describeTableRolls lists the rolls made on each table in order, e.g. "encounters d6: 4".
+func describeTableRolls(result *dice.TableResult) []string {
+	rolls := []string{fmt.Sprintf("%s d%d: %d", result.Table, result.Die, result.Roll)}
+	for _, nested := range result.Nested {
+		rolls = append(rolls, describeTableRolls(nested)...)
+	}
+	return rolls
+}
+
+// hasRandomTable reports whether a table with the given name is in the list.
+func hasRandomTable(tables []db.RandomTable, name string) bool {
+	for _, table := range tables {
+		if table.Name == name {
+			return true
+		}
+	}
+	return false
+}
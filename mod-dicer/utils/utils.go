@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/base64"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
 	"net"
@@ -12,6 +13,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // FormatDuration formats the given seconds into HH:MM:SS format.
@@ -37,6 +39,30 @@ func ReadFileToBase64(filePath string) (string, error) {
 	return fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(fileContent), base64Content), nil
 }
 
+// DownloadFile fetches the body of the URL, failing when it is larger than limit bytes.
+// Example: content, err := DownloadFile(attachment.URL, 64<<10)
+func DownloadFile(url string, limit int64) ([]byte, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the file: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading the file: %s", resp.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading the file: %v", err)
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("the file is larger than %d KB", limit>>10)
+	}
+	return content, nil
+}
+
 // SanitizeString removes unwanted characters from the input string.
 // Example: sanitizedStr := SanitizeString("Hello#World!")
 func SanitizeString(input string) string {
@@ -47,7 +73,7 @@ func SanitizeString(input string) string {
 // InferProtocolByPort attempts to infer the protocol based on the availability of a specific port.
 // Example: protocol := InferProtocolByPort("example.com", 443)
 func InferProtocolByPort(hostname string, port int) string {
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", hostname, port))
+	conn, err := net.Dial("tcp", net.JoinHostPort(hostname, strconv.Itoa(port)))
 	if err != nil {
 		// Assuming it's not available, default to HTTP
 		return "http://"
@@ -0,0 +1,80 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+	"github.com/keshon/dice-roller/internal/lifecycle"
+	"github.com/keshon/dice-roller/internal/router"
+	"github.com/keshon/dice-roller/internal/settings"
+	"github.com/keshon/dice-roller/mod-generator/generator"
+)
+
+// Discord represents the generator instance for Discord. One instance serves every guild it is started in.
+type Discord struct {
+	Session *discordgo.Session
+	router  *router.Router
+	guilds  *lifecycle.Guilds[struct{}]
+}
+
+// NewDiscord creates a new instance of Discord and mounts its commands and handlers, shared by the guilds it serves.
+func NewDiscord(session *discordgo.Session, r *router.Router) *Discord {
+	d := &Discord{
+		Session: session,
+		router:  r,
+		guilds:  lifecycle.NewGuilds[struct{}](nil),
+	}
+
+	r.Mount(router.Global, router.Module{Name: "generator", Commands: d.commands(), Serves: d.guilds.Serves})
+	session.AddHandler(d.Interactions)
+	return d
+}
+
+// Start serves the guild, until Stop is called or the context is done.
+// It fails when the built-in generators can't be loaded.
+func (d *Discord) Start(ctx context.Context, guildID string) error {
+	guild, err := d.guilds.Start(ctx, guildID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := generator.Builtin(); err != nil {
+		return guild.Fail(fmt.Errorf("loading built-in generators: %w", err))
+	}
+
+	slog.Infof(`Discord instance of mod-generator started for guild id %v`, guildID)
+	return nil
+}
+
+// Stop stops serving the guild.
+func (d *Discord) Stop(guildID string) {
+	d.guilds.Stop(guildID)
+}
+
+// State returns the state of the Discord instance in the guild.
+func (d *Discord) State(guildID string) lifecycle.State {
+	return d.guilds.State(guildID)
+}
+
+// settings returns the settings of the guild.
+func (d *Discord) settings(guildID string) settings.Settings {
+	return d.router.GuildSettings(guildID)
+}
+
+// commands returns the commands of the module.
+func (d *Discord) commands() []*router.Command {
+	return []*router.Command{
+		{
+			Name: "gen", Aliases: []string{"generate"},
+			Args:        []router.Arg{{Name: "generator", Optional: true}, {Name: "variant", Optional: true}},
+			Category:    router.CategoryGenerators,
+			Description: "NPC names, taverns, trinkets and treasure hoards, `gen` lists the generators. Upload your own with a JSON definition attached",
+			Examples:    []string{"gen tavern", "gen name elf", "gen hoard 7", "gen tavern seed 42", "gen upload", "gen remove tavern"},
+			Permission:  discordgo.PermissionManageServer,
+			Restricted:  router.Subcommands("gen", "upload", "add", "remove", "delete"),
+			Handler:     router.Handle(d.handleGenerateCommand),
+		},
+	}
+}
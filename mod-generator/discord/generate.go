@@ -0,0 +1,365 @@
+package discord
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	embed "github.com/Clinet/discordgo-embed"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gookit/slog"
+
+	"github.com/keshon/dice-roller/internal/db"
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+	"github.com/keshon/dice-roller/mod-dicer/utils"
+	"github.com/keshon/dice-roller/mod-generator/generator"
+)
+
+const (
+	rerollButtonPrefix = "generator:reroll:"
+	// maxCustomID is the length of the custom ID of a button Discord accepts.
+	maxCustomID = 100
+
+	maxCustomGenerators  = 25
+	maxGeneratorFileSize = 64 << 10
+)
+
+// handleGenerateCommand generates a result, e.g. "gen tavern", "gen name elf" or "gen hoard 7 seed 1234",
+// or manages the guild generators with "gen upload" and "gen remove".
+func (d *Discord) handleGenerateCommand(s *discordgo.Session, m *discordgo.MessageCreate, param string) {
+	fields := strings.Fields(param)
+	if len(fields) == 0 {
+		d.listGenerators(s, m)
+		return
+	}
+
+	switch fields[0] {
+	case "upload", "add":
+		d.handleGeneratorUpload(s, m)
+		return
+	case "remove", "delete":
+		d.handleGeneratorRemove(s, m, fields[1:])
+		return
+	}
+
+	name, fields := fields[0], fields[1:]
+
+	seed := newSeed()
+	for i := 0; i < len(fields); i++ {
+		if fields[i] != "seed" {
+			continue
+		}
+		if i+1 == len(fields) {
+			s.ChannelMessageSend(m.ChannelID, "Error: give the seed number, e.g. `gen tavern seed 1234`")
+			return
+		}
+
+		var err error
+		seed, err = strconv.ParseInt(fields[i+1], 10, 64)
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "Error: the seed should be a number, e.g. `gen tavern seed 1234`")
+			return
+		}
+		fields = append(fields[:i], fields[i+2:]...)
+		break
+	}
+
+	variant := strings.Join(fields, " ")
+	embedMsg, err := d.generate(m.GuildID, name, variant, seed)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	_, err = s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embedMsg},
+		Components: rerollButtons(name, variant),
+	})
+	if err != nil {
+		slog.Errorf("Error sending generated result: %v", err)
+	}
+}
+
+// Interactions handles the reroll buttons of generated results.
+func (d *Discord) Interactions(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent || !d.guilds.Serves(i.GuildID) {
+		return
+	}
+
+	customID := i.MessageComponentData().CustomID
+	if !strings.HasPrefix(customID, rerollButtonPrefix) {
+		return
+	}
+
+	name, variant, _ := strings.Cut(strings.TrimPrefix(customID, rerollButtonPrefix), ":")
+
+	embedMsg, err := d.generate(i.GuildID, name, variant, newSeed())
+	if err != nil {
+		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("Error: %v", err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			slog.Errorf("Error responding to interaction: %v", err)
+		}
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embedMsg},
+			Components: rerollButtons(name, variant),
+		},
+	})
+	if err != nil {
+		slog.Errorf("Error responding to interaction: %v", err)
+	}
+}
+
+// generate runs the generator with a source seeded by the given seed, so the same seed gives the same result.
+func (d *Discord) generate(guildID, name, variant string, seed int64) (*discordgo.MessageEmbed, error) {
+	g, err := d.generator(guildID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := g.Generate(dice.NewSeededSource(seed), variant)
+	if err != nil {
+		return nil, err
+	}
+
+	title := capitalize(output.Generator)
+	if output.Variant != "" {
+		title += " (" + output.Variant + ")"
+	}
+
+	return embed.NewEmbed().
+		SetTitle("✨ " + title).
+		SetDescription(output.Text).
+		SetFooter(fmt.Sprintf("%s · seed %d", g.Description, seed)).
+		SetColor(d.settings(guildID).EmbedColor).MessageEmbed, nil
+}
+
+// handleGeneratorUpload stores the JSON generator definition attached to the message.
+// A definition named after a built-in generator extends it.
+func (d *Discord) handleGeneratorUpload(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if len(m.Attachments) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Error: attach a JSON generator definition to `gen upload`")
+		return
+	}
+
+	attachment := m.Attachments[0]
+	if attachment.Size > maxGeneratorFileSize {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: generator definitions can be up to %d KB", maxGeneratorFileSize>>10))
+		return
+	}
+
+	content, err := utils.DownloadFile(attachment.URL, maxGeneratorFileSize)
+	if err != nil {
+		slog.Errorf("Error downloading generator: %v", err)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	g, err := generator.Parse(content)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	builtin, err := generator.Builtin()
+	if err != nil {
+		slog.Errorf("Error loading built-in generators: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error saving the generator")
+		return
+	}
+
+	message := fmt.Sprintf("Generator `%s` saved, try it with `%sgen %s`", g.Name, d.settings(m.GuildID).Prefix, g.Name)
+	effective := g
+	if base, ok := builtin[g.Name]; ok {
+		effective = base.Extend(g)
+		if err := effective.Validate(); err != nil {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+			return
+		}
+		message = fmt.Sprintf("Built-in generator `%s` extended, try it with `%sgen %s`", g.Name, d.settings(m.GuildID).Prefix, g.Name)
+	}
+
+	if err := effective.Try(dice.Crypto); err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	existing, err := db.GetCustomGenerators(m.GuildID)
+	if err != nil {
+		slog.Errorf("Error loading custom generators: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error saving the generator")
+		return
+	}
+	if len(existing) >= maxCustomGenerators && !hasCustomGenerator(existing, g.Name) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: up to %d generators can be uploaded, `gen remove` some first", maxCustomGenerators))
+		return
+	}
+
+	err = db.SaveCustomGenerator(db.CustomGenerator{GuildID: m.GuildID, Name: g.Name, Definition: string(content)})
+	if err != nil {
+		slog.Errorf("Error saving custom generator: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error saving the generator")
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, message)
+}
+
+// handleGeneratorRemove removes an uploaded generator, restoring the built-in one it extended.
+func (d *Discord) handleGeneratorRemove(s *discordgo.Session, m *discordgo.MessageCreate, fields []string) {
+	if len(fields) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Error: give the name of the generator, e.g. `gen remove tavern`")
+		return
+	}
+
+	found, err := db.DeleteCustomGenerator(m.GuildID, fields[0])
+	if err != nil {
+		slog.Errorf("Error deleting custom generator: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error removing the generator")
+		return
+	}
+
+	if !found {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Generator `%s` is not uploaded", fields[0]))
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Generator `%s` removed", fields[0]))
+}
+
+// listGenerators shows the built-in and uploaded generators with their variants.
+func (d *Discord) listGenerators(s *discordgo.Session, m *discordgo.MessageCreate) {
+	builtin, err := generator.Builtin()
+	if err != nil {
+		slog.Errorf("Error loading built-in generators: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error loading generators")
+		return
+	}
+
+	custom, err := db.GetCustomGenerators(m.GuildID)
+	if err != nil {
+		slog.Errorf("Error loading custom generators: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "Error loading generators")
+		return
+	}
+
+	names := make([]string, 0, len(builtin)+len(custom))
+	for name := range builtin {
+		names = append(names, name)
+	}
+	for _, c := range custom {
+		if _, ok := builtin[c.Name]; !ok {
+			names = append(names, c.Name)
+		}
+	}
+	sort.Strings(names)
+
+	embedMsg := embed.NewEmbed().SetTitle("Generators").SetColor(d.settings(m.GuildID).EmbedColor)
+	for _, name := range names {
+		g, err := d.generator(m.GuildID, name)
+		if err != nil {
+			embedMsg.AddField("`"+name+"`", fmt.Sprintf("Error: %v", err))
+			continue
+		}
+
+		description := g.Description
+		if variants := g.VariantNames(); len(variants) > 0 {
+			description += "\n" + strings.Join(variants, ", ")
+		}
+		if hasCustomGenerator(custom, name) {
+			description += "\n*uploaded by the guild*"
+		}
+		embedMsg.AddField("`"+name+"`", description)
+	}
+
+	s.ChannelMessageSendEmbed(m.ChannelID, embedMsg.MessageEmbed)
+}
+
+// generator returns the named generator, a built-in one extended by the guild upload if there is one.
+func (d *Discord) generator(guildID, name string) (*generator.Generator, error) {
+	name = strings.ToLower(name)
+
+	builtin, err := generator.Builtin()
+	if err != nil {
+		slog.Errorf("Error loading built-in generators: %v", err)
+		return nil, fmt.Errorf("can't load generator %q", name)
+	}
+
+	stored, err := db.GetCustomGenerator(guildID, name)
+	if err != nil {
+		slog.Errorf("Error loading custom generator: %v", err)
+		return nil, fmt.Errorf("can't load generator %q", name)
+	}
+
+	base, ok := builtin[name]
+	switch {
+	case stored == nil && !ok:
+		return nil, fmt.Errorf("unknown generator %q, see `gen`", name)
+	case stored == nil:
+		return base, nil
+	}
+
+	custom, err := generator.Parse([]byte(stored.Definition))
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return base.Extend(custom), nil
+	}
+	return custom, nil
+}
+
+// rerollButtons returns the reroll button of a generated result. The generator and variant names can't hold
+// a colon, so the custom ID splits back into them, and a result whose custom ID would be too long gets no button.
+func rerollButtons(name, variant string) []discordgo.MessageComponent {
+	customID := rerollButtonPrefix + name + ":" + variant
+	if len(customID) > maxCustomID {
+		return nil
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Reroll",
+					Emoji:    discordgo.ComponentEmoji{Name: "🎲"},
+					Style:    discordgo.PrimaryButton,
+					CustomID: customID,
+				},
+			},
+		},
+	}
+}
+
+// newSeed picks a random seed that is short enough to be typed back.
+func newSeed() int64 {
+	return int64(dice.Crypto.Intn(math.MaxInt32))
+}
+
+// hasCustomGenerator reports whether a generator with the given name is in the list.
+func hasCustomGenerator(generators []db.CustomGenerator, name string) bool {
+	for _, g := range generators {
+		if g.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// capitalize upper-cases the first letter of an ASCII word.
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
+}
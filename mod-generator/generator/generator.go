@@ -0,0 +1,365 @@
+package generator
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+const (
+	// MaxDepth is how deep list references can be nested, it also stops reference loops.
+	MaxDepth = 10
+	// MaxName is the length of a generator name.
+	MaxName = 32
+	// MaxPlaceholders is how many placeholders a single generation expands, so lists whose entries
+	// hold many placeholders can't multiply into billions of expansions.
+	MaxPlaceholders = 1000
+	// MaxText is the length of a generated text, it fits in the 4096 characters of an embed description.
+	MaxText = 4000
+)
+
+var (
+	namePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+	// placeholderPattern matches a list reference or a dice expression in a template, e.g. "{tavern-noun}" or "{6d6*100}".
+	placeholderPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+)
+
+//go:embed data/*.json
+var builtinData embed.FS
+
+// Variant is an alternative template of a generator, e.g. a culture or a challenge rating tier.
+// Min and Max let a number such as a challenge rating pick the variant.
+type Variant struct {
+	Template string `json:"template"`
+	Min      *int   `json:"min,omitempty"`
+	Max      *int   `json:"max,omitempty"`
+}
+
+// Generator builds text from a template whose placeholders pick entries of its lists or roll dice.
+type Generator struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Template    string              `json:"template,omitempty"`
+	Variants    map[string]Variant  `json:"variants,omitempty"`
+	Lists       map[string][]string `json:"lists"`
+}
+
+// Output is a generated result.
+type Output struct {
+	Generator string
+	Variant   string
+	Text      string
+}
+
+// Parse reads a generator definition from JSON and validates it.
+func Parse(data []byte) (*Generator, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var g Generator
+	if err := decoder.Decode(&g); err != nil {
+		return nil, fmt.Errorf("invalid generator definition: %v", err)
+	}
+
+	g.Name = strings.ToLower(g.Name)
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// Validate checks the names of the generator and its variants, and that every placeholder is a known list or a valid dice expression.
+func (g *Generator) Validate() error {
+	if len(g.Name) > MaxName || !namePattern.MatchString(g.Name) {
+		return fmt.Errorf("generator names start with a letter and use up to %d letters, digits, `-` or `_`", MaxName)
+	}
+	if g.Template == "" && len(g.Variants) == 0 {
+		return fmt.Errorf("generator %q needs a template or variants", g.Name)
+	}
+
+	templates := []string{g.Template}
+	for name, variant := range g.Variants {
+		if len(name) > MaxName || !namePattern.MatchString(name) {
+			return fmt.Errorf("variant %q of generator %q: variant names start with a lowercase letter and use up to %d letters, digits, `-` or `_`", name, g.Name, MaxName)
+		}
+		if variant.Template == "" {
+			return fmt.Errorf("variant %q of generator %q has no template", name, g.Name)
+		}
+		templates = append(templates, variant.Template)
+	}
+	for name, entries := range g.Lists {
+		if len(entries) == 0 {
+			return fmt.Errorf("list %q of generator %q is empty", name, g.Name)
+		}
+		templates = append(templates, entries...)
+	}
+
+	for _, template := range templates {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+			if _, ok := g.Lists[match[1]]; ok {
+				continue
+			}
+			if _, _, err := parseDice(match[1]); err != nil {
+				return fmt.Errorf("{%s} in generator %q is neither a list nor a dice expression: %v", match[1], g.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// VariantNames returns the names of the variants in alphabetical order.
+func (g *Generator) VariantNames() []string {
+	names := make([]string, 0, len(g.Variants))
+	for name := range g.Variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate expands the template of the generator, or of one of its variants, using the random source.
+// The variant can be given by name or by a number within its range, e.g. "elf" or "7" for a challenge rating.
+// Without a variant the base template is used, or a random variant when there is none.
+func (g *Generator) Generate(src dice.Source, variant string) (*Output, error) {
+	name, template, err := g.pickTemplate(src, strings.ToLower(variant))
+	if err != nil {
+		return nil, err
+	}
+
+	budget := MaxPlaceholders
+	text, err := g.expand(dice.NewRoller(src), template, 0, &budget)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Output{Generator: g.Name, Variant: name, Text: text}, nil
+}
+
+// pickTemplate finds the template of the requested variant.
+func (g *Generator) pickTemplate(src dice.Source, variant string) (string, string, error) {
+	if variant == "" {
+		if g.Template != "" {
+			return "", g.Template, nil
+		}
+		names := g.VariantNames()
+		name := names[src.Intn(len(names))]
+		return name, g.Variants[name].Template, nil
+	}
+
+	if v, ok := g.Variants[variant]; ok {
+		return variant, v.Template, nil
+	}
+
+	if number, err := strconv.Atoi(variant); err == nil {
+		for _, name := range g.VariantNames() {
+			v := g.Variants[name]
+			if v.Min != nil && v.Max != nil && number >= *v.Min && number <= *v.Max {
+				return name, v.Template, nil
+			}
+		}
+	}
+
+	if len(g.Variants) == 0 {
+		return "", "", fmt.Errorf("generator %q has no variants", g.Name)
+	}
+	return "", "", fmt.Errorf("unknown variant %q, use %s", variant, strings.Join(g.VariantNames(), ", "))
+}
+
+// expand replaces the placeholders of a template, picking list entries and expanding them in turn.
+// Each placeholder takes one from the budget, and the expansion fails once it runs out.
+func (g *Generator) expand(roller *dice.Roller, template string, depth int, budget *int) (string, error) {
+	if depth > MaxDepth {
+		return "", fmt.Errorf("lists of generator %q are nested deeper than %d levels", g.Name, MaxDepth)
+	}
+
+	var expandErr error
+	text := placeholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		if expandErr != nil {
+			return placeholder
+		}
+
+		*budget--
+		if *budget < 0 {
+			expandErr = fmt.Errorf("generator %q expands more than %d placeholders", g.Name, MaxPlaceholders)
+			return placeholder
+		}
+
+		key := placeholder[1 : len(placeholder)-1]
+		if entries, ok := g.Lists[key]; ok {
+			entry, err := g.expand(roller, entries[roller.Source.Intn(len(entries))], depth+1, budget)
+			if err != nil {
+				expandErr = err
+			}
+			return entry
+		}
+
+		value, err := rollDice(roller, key)
+		if err != nil {
+			expandErr = err
+			return placeholder
+		}
+		return value
+	})
+
+	if expandErr == nil && len(text) > MaxText {
+		expandErr = fmt.Errorf("generator %q writes more than %d characters", g.Name, MaxText)
+	}
+	return text, expandErr
+}
+
+// Try generates the template and every variant once, so a definition whose lists expand
+// beyond the limits is refused when it is uploaded rather than when it is used.
+func (g *Generator) Try(src dice.Source) error {
+	variants := g.VariantNames()
+	if g.Template != "" {
+		variants = append([]string{""}, variants...)
+	}
+
+	for _, variant := range variants {
+		if _, err := g.Generate(src, variant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDice splits a dice placeholder such as "6d6*100" into its expression and multiplier.
+func parseDice(placeholder string) (*dice.Expression, int, error) {
+	expr, multiplier := placeholder, 1
+	if before, after, found := strings.Cut(placeholder, "*"); found {
+		m, err := strconv.Atoi(strings.TrimSpace(after))
+		if err != nil || m < 1 {
+			return nil, 0, fmt.Errorf("invalid multiplier in {%s}", placeholder)
+		}
+		expr, multiplier = before, m
+	}
+
+	parsed, err := dice.Parse(expr)
+	if err != nil {
+		return nil, 0, err
+	}
+	if parsed.Check != nil {
+		return nil, 0, fmt.Errorf("checks are not allowed in {%s}", placeholder)
+	}
+	return parsed, multiplier, nil
+}
+
+// rollDice rolls a dice placeholder and formats the total with thousands separators.
+func rollDice(roller *dice.Roller, placeholder string) (string, error) {
+	expr, multiplier, err := parseDice(placeholder)
+	if err != nil {
+		return "", err
+	}
+	if err := roller.Resolve(expr); err != nil {
+		return "", err
+	}
+	return formatNumber(roller.Roll(expr).Total * multiplier), nil
+}
+
+// formatNumber writes a number with comma thousands separators, e.g. "12,400".
+func formatNumber(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var sb strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(digit)
+	}
+	return sign + sb.String()
+}
+
+// Extend returns a copy of the generator with the extension merged in: list entries are appended,
+// while the description, template and variants of the extension replace those of the generator.
+func (g *Generator) Extend(ext *Generator) *Generator {
+	merged := &Generator{
+		Name:        g.Name,
+		Description: g.Description,
+		Template:    g.Template,
+		Variants:    make(map[string]Variant, len(g.Variants)+len(ext.Variants)),
+		Lists:       make(map[string][]string, len(g.Lists)+len(ext.Lists)),
+	}
+	if ext.Description != "" {
+		merged.Description = ext.Description
+	}
+	if ext.Template != "" {
+		merged.Template = ext.Template
+	}
+
+	for name, variant := range g.Variants {
+		merged.Variants[name] = variant
+	}
+	for name, variant := range ext.Variants {
+		merged.Variants[name] = variant
+	}
+
+	for name, entries := range g.Lists {
+		merged.Lists[name] = append([]string(nil), entries...)
+	}
+	for name, entries := range ext.Lists {
+		merged.Lists[name] = append(merged.Lists[name], entries...)
+	}
+
+	return merged
+}
+
+var (
+	builtinOnce       sync.Once
+	builtinGenerators map[string]*Generator
+	builtinErr        error
+)
+
+// Builtin returns the generators shipped with the module, keyed by name.
+func Builtin() (map[string]*Generator, error) {
+	builtinOnce.Do(func() {
+		data, err := fs.Sub(builtinData, "data")
+		if err != nil {
+			builtinErr = err
+			return
+		}
+		builtinGenerators, builtinErr = Load(data)
+	})
+	return builtinGenerators, builtinErr
+}
+
+// Load reads every JSON generator definition at the root of the file system.
+func Load(fsys fs.FS) (map[string]*Generator, error) {
+	paths, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	generators := make(map[string]*Generator)
+	for _, path := range paths {
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+
+		g, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if _, exists := generators[g.Name]; exists {
+			return nil, fmt.Errorf("%s: generator %q is defined twice", path, g.Name)
+		}
+		generators[g.Name] = g
+	}
+
+	return generators, nil
+}
@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keshon/dice-roller/mod-dicer/dice"
+)
+
+// fixedSource returns the queued values in order, repeating the last one.
+type fixedSource struct {
+	values []int
+}
+
+func (f *fixedSource) Intn(n int) int {
+	v := f.values[0]
+	if len(f.values) > 1 {
+		f.values = f.values[1:]
+	}
+	return v % n
+}
+
+func TestBuiltin(t *testing.T) {
+	generators, err := Builtin()
+	assert.NoError(t, err)
+	for _, name := range []string{"name", "tavern", "trinket", "hoard"} {
+		assert.Contains(t, generators, name)
+	}
+
+	t.Run("Reproducible", func(t *testing.T) {
+		for name, g := range generators {
+			first, err := g.Generate(dice.NewSeededSource(42), "")
+			assert.NoError(t, err, name)
+			again, err := g.Generate(dice.NewSeededSource(42), "")
+			assert.NoError(t, err, name)
+			assert.Equal(t, first, again, name)
+			assert.NotContains(t, first.Text, "{", name)
+		}
+	})
+
+	t.Run("VariantByNumber", func(t *testing.T) {
+		output, err := generators["hoard"].Generate(dice.NewSeededSource(1), "7")
+		assert.NoError(t, err)
+		assert.Equal(t, "cr5-10", output.Variant)
+
+		_, err = generators["hoard"].Generate(dice.NewSeededSource(1), "40")
+		assert.Error(t, err)
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	g, err := Parse([]byte(`{
+		"name": "Loot",
+		"template": "{count} coins, {2d6*100} gp",
+		"lists": {"count": ["{1d4+1}", "no"], "unused": ["x"]}
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "loot", g.Name)
+
+	// list entry 0, 1d4 rolls 3, 2d6 roll 6 and 6
+	output, err := g.Generate(&fixedSource{values: []int{0, 2, 5}}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "4 coins, 1,200 gp", output.Text)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, definition := range []string{
+		`{"name": "x"}`,
+		`{"name": "9x", "template": "a"}`,
+		`{"name": "x", "template": "{missing}"}`,
+		`{"name": "x", "template": "{1d6*0}"}`,
+		`{"name": "x", "template": "{1d20 vs 10}"}`,
+		`{"name": "x", "template": "a", "lists": {"empty": []}}`,
+		`{"name": "x", "template": "a", "unknown": 1}`,
+		`{"name": "x", "variants": {"a:b": {"template": "a"}}}`,
+		`{"name": "x", "variants": {"` + strings.Repeat("a", MaxName+1) + `": {"template": "a"}}}`,
+	} {
+		_, err := Parse([]byte(definition))
+		assert.Error(t, err, definition)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	g, err := Parse([]byte(`{"name": "loop", "template": "{a}", "lists": {"a": ["{a}"]}}`))
+	assert.NoError(t, err)
+
+	_, err = g.Generate(&fixedSource{values: []int{0}}, "")
+	assert.ErrorContains(t, err, "nested deeper")
+}
+
+func TestBudget(t *testing.T) {
+	// nine levels of lists with 100 placeholders each would expand 100^9 times
+	lists := make(map[string][]string)
+	for i := 0; i < 9; i++ {
+		lists[fmt.Sprint("l", i)] = []string{strings.Repeat(fmt.Sprintf("{l%d}", i+1), 100)}
+	}
+	lists["l9"] = []string{"x"}
+	g := &Generator{Name: "bomb", Template: "{l0}", Lists: lists}
+	assert.NoError(t, g.Validate())
+
+	_, err := g.Generate(dice.Crypto, "")
+	assert.ErrorContains(t, err, "more than 1000 placeholders")
+	assert.Error(t, g.Try(dice.Crypto))
+
+	long := &Generator{Name: "long", Template: "{a}{a}{a}{a}{a}", Lists: map[string][]string{"a": {strings.Repeat("x", 1000)}}}
+	_, err = long.Generate(dice.Crypto, "")
+	assert.ErrorContains(t, err, "more than 4000 characters")
+
+	builtin, err := Builtin()
+	assert.NoError(t, err)
+	for name, g := range builtin {
+		assert.NoError(t, g.Try(dice.Crypto), name)
+	}
+}
+
+func TestExtend(t *testing.T) {
+	base, _ := Parse([]byte(`{"name": "tavern", "template": "The {noun}", "lists": {"noun": ["Boar"]}}`))
+	ext, _ := Parse([]byte(`{"name": "tavern", "template": "The {noun}", "lists": {"noun": ["Kraken"]}}`))
+
+	merged := base.Extend(ext)
+	assert.Equal(t, []string{"Boar", "Kraken"}, merged.Lists["noun"])
+	assert.Equal(t, []string{"Boar"}, base.Lists["noun"])
+	assert.NoError(t, merged.Validate())
+}
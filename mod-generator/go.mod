@@ -0,0 +1,11 @@
+module github.com/keshon/dice-roller/mod-generator
+
+go 1.21.1
+
+require (
+	github.com/Clinet/discordgo-embed v0.0.0-20220113222025-bafe0c917646
+	github.com/bwmarrin/discordgo v0.27.1
+	github.com/gookit/slog v0.5.5
+	github.com/keshon/dice-roller v0.0.0-20240213202749-620d27a8f566
+	github.com/stretchr/testify v1.8.4
+)